@@ -37,6 +37,17 @@ func (buffer *byteBuffer) writeIndent(indent int) {
 	}
 }
 
+func (buffer *byteBuffer) trimTrailingNewline() {
+	if length := len(*buffer); length != 0 && (*buffer)[length-1] == '\n' {
+		*buffer = (*buffer)[:length-1]
+	}
+}
+
+// Removes everything after the given length from the buffer, to undo writes.
+func (buffer *byteBuffer) truncate(length int) {
+	*buffer = (*buffer)[:length]
+}
+
 func (buffer *byteBuffer) writeAny(value any) {
 	*buffer = fmt.Append(*buffer, value)
 }
@@ -66,6 +77,27 @@ func (buffer *byteBuffer) writeDateTime(t time.Time) {
 	buffer.writeTime(t)
 }
 
+// Writes the given duration as minutes, seconds and milliseconds (01:02.345), prefixed by hours if
+// the duration is an hour or more (1:01:02.345). Negative durations are written as zero.
+func (buffer *byteBuffer) writeElapsedTime(elapsed time.Duration) {
+	elapsed = max(elapsed, 0)
+
+	hours := int(elapsed / time.Hour)
+	minutes := int(elapsed / time.Minute % 60)
+	seconds := int(elapsed / time.Second % 60)
+	milliseconds := int(elapsed / time.Millisecond % 1000)
+
+	if hours > 0 {
+		buffer.writeDecimal(hours)
+		buffer.writeByte(':')
+	}
+	buffer.writeFixedWidthDecimal(minutes, 2)
+	buffer.writeByte(':')
+	buffer.writeFixedWidthDecimal(seconds, 2)
+	buffer.writeByte('.')
+	buffer.writeFixedWidthDecimal(milliseconds, 3)
+}
+
 // Adapted from standard library log package:
 // https://github.com/golang/go/blob/ab5bd15941f3cea3695338756d0b8be0ef2321fb/src/log/log.go#L93
 func (buffer *byteBuffer) writeFixedWidthDecimal(decimal int, width int) {
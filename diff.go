@@ -0,0 +1,101 @@
+package devlog
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// Implemented by the attribute value from log.Diff in the log subpackage. Should be the same method
+// as in log/diff.go.
+type diffValuer interface {
+	DiffChanges() []slog.Attr
+}
+
+// Writes the changes from log.Diff as a diff, with one line per changed path:
+//
+//	~ name: "Alice" → "Bob"
+//	+ email: "bob@example.com"
+//	- phone: "12345678"
+//
+// Returns false if there are no changes, following the slog convention of ignoring empty groups.
+func (handler *Handler) writeDiff(
+	buffer *byteBuffer,
+	attrKey string,
+	changes []slog.Attr,
+	indent int,
+) bool {
+	if len(changes) == 0 {
+		return false
+	}
+
+	buffer.writeIndent(indent)
+	handler.writeAttributeKey(buffer, attrKey)
+	buffer.writeByte('\n')
+
+	for _, change := range changes {
+		if change.Value.Kind() != slog.KindGroup {
+			continue
+		}
+
+		var before, after any
+		var hasBefore, hasAfter bool
+		for _, attr := range change.Value.Group() {
+			switch attr.Key {
+			case diffBeforeKey:
+				before, hasBefore = attr.Value.Any(), true
+			case diffAfterKey:
+				after, hasAfter = attr.Value.Any(), true
+			}
+		}
+
+		buffer.writeIndent(indent + 1)
+		switch {
+		case hasBefore && hasAfter:
+			handler.writeByteWithColor(buffer, '~', colorYellow)
+			buffer.writeByte(' ')
+			handler.writeDiffPath(buffer, change.Key)
+			handler.writeDiffValue(buffer, before, colorRed)
+			handler.setColor(buffer, colorGray)
+			buffer.writeString(" → ")
+			handler.resetColor(buffer)
+			handler.writeDiffValue(buffer, after, colorGreen)
+		case hasAfter:
+			handler.writeByteWithColor(buffer, '+', colorGreen)
+			buffer.writeByte(' ')
+			handler.writeDiffPath(buffer, change.Key)
+			handler.writeDiffValue(buffer, after, colorGreen)
+		case hasBefore:
+			handler.writeByteWithColor(buffer, '-', colorRed)
+			buffer.writeByte(' ')
+			handler.writeDiffPath(buffer, change.Key)
+			handler.writeDiffValue(buffer, before, colorRed)
+		}
+		buffer.writeByte('\n')
+	}
+
+	return true
+}
+
+func (handler *Handler) writeDiffPath(buffer *byteBuffer, path string) {
+	buffer.writeString(path)
+	handler.writeByteWithColor(buffer, ':', colorGray)
+	buffer.writeByte(' ')
+}
+
+// Writes the given value from a diff as compact JSON, so that strings are quoted (to distinguish
+// "" from a missing value), and nested objects fit on one line.
+func (handler *Handler) writeDiffValue(buffer *byteBuffer, value any, color color) {
+	handler.setColor(buffer, color)
+	if encoded, err := json.Marshal(value); err == nil {
+		buffer.write(encoded)
+	} else {
+		buffer.writeAny(value)
+	}
+	handler.resetColor(buffer)
+}
+
+// Should be the same keys as in log/diff.go.
+const (
+	diffBeforeKey = "before"
+	diffAfterKey  = "after"
+)
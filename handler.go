@@ -5,11 +5,14 @@ import (
 	"io"
 	"log/slog"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/neilotoole/jsoncolor"
+
+	"hermannm.dev/devlog/internal/messagetemplate"
 )
 
 // Handler is a [slog.Handler] that outputs log records in a human-readable format, designed for
@@ -24,6 +27,9 @@ type Handler struct {
 	preformattedAttrs           byteBuffer
 	preformattedGroups          byteBuffer
 	preformattedGroupsWithAttrs byteBuffer
+
+	// Time when the handler was created, used for [TimeFormatRelative].
+	startTime time.Time
 }
 
 // Options configure a log [Handler].
@@ -51,6 +57,72 @@ type Options struct {
 	// [TimeFormatShort], showing just the time and not the date, but can be set to [TimeFormatFull]
 	// to include the date as well.
 	TimeFormat TimeFormat
+
+	// Clock is used to get the current time when creating the handler, which is the start time for
+	// [TimeFormatRelative]. You can set this to a fake clock in tests, to get deterministic output.
+	// The handler formats the time of log records as given, so to control the time of records as
+	// well, use a logger with the same clock (see log.Logger.WithClock in the
+	// [hermannm.dev/devlog/log] subpackage).
+	// If nil, defaults to the system clock.
+	Clock Clock
+
+	// ValueRenderers customize how attribute values are formatted, for example for your own types
+	// (see [RenderType]). For each attribute value, the renderers are tried in order, and the first
+	// one that handles the value is used. If none of them do, the value is formatted as follows:
+	//   - [time.Duration] is rounded to 3 significant digits (1.23s, 45.6ms)
+	//   - [time.Time] includes the date, and the time zone if it's not local time
+	//   - [ByteSize] uses binary unit prefixes (1.5 KiB)
+	//   - []byte is shown as hex, truncated to 32 bytes, along with its length
+	//   - [net.IP] and [url.URL] use their string formats (with passwords in URLs redacted)
+	//   - Strings and other primitive types are shown as-is
+	//   - Other types are formatted as JSON
+	ValueRenderers []ValueRenderer
+
+	// MaxStringLength is the maximum length in bytes of string attribute values (including strings
+	// in values formatted as JSON). Longer strings are cut off, followed by a marker with the size
+	// of the truncated part, such as "…(truncated 12.3 KiB)".
+	// If 0, strings are not truncated.
+	MaxStringLength int
+
+	// MaxJSONDepth is the maximum nesting depth of objects and arrays in attribute values formatted
+	// as JSON (such as structs, maps and slices). Deeper values are replaced by a truncation marker
+	// with their size. The attribute value itself has depth 1.
+	// If 0, JSON is not truncated by depth.
+	MaxJSONDepth int
+
+	// MaxArrayElements is the maximum number of elements to show for arrays in attribute values
+	// formatted as JSON. Arrays with more elements are cut off, followed by a marker with the number
+	// of truncated elements.
+	// If 0, arrays are not truncated.
+	MaxArrayElements int
+
+	// MaxRecordSize is the maximum size in bytes of the output for a single log record. If a record
+	// exceeds this, its output is cut off at the last full line within the limit, followed by a
+	// truncation marker.
+	// If 0, records are not truncated.
+	MaxRecordSize int
+
+	// DisableSyntaxHighlighting turns off syntax highlighting of multi-line string attribute values
+	// that contain JSON or SQL (detected by starting with an uppercase keyword such as SELECT).
+	// Multi-line strings are shown as an indented block under the attribute key, and highlighted if
+	// colors are enabled.
+	DisableSyntaxHighlighting bool
+
+	// RenderTables enables showing slices of structs or maps as aligned tables under the attribute
+	// key, with a column for each JSON field, instead of as JSON arrays. Attributes from log.Table
+	// in the log subpackage are always shown as tables.
+	RenderTables bool
+
+	// MaxTableColumnWidth is the maximum width in characters of table columns (see RenderTables).
+	// Longer cell values are cut off with "…".
+	// If 0, defaults to 40.
+	MaxTableColumnWidth int
+}
+
+// Clock provides the current time. It's implemented by fake clocks in tests, such as the one in the
+// [hermannm.dev/devlog/log/logtest] subpackage.
+type Clock interface {
+	Now() time.Time
 }
 
 // TimeFormat is the type for valid constants for [Options.TimeFormat].
@@ -67,6 +139,12 @@ const (
 
 	// TimeFormatNone excludes time from the log output.
 	TimeFormatNone
+
+	// TimeFormatRelative shows the time elapsed since the handler was created, with millisecond
+	// precision, formatted as: [+01:02.345]
+	//
+	// If more than an hour has elapsed, hours are included as well: [+1:01:02.345]
+	TimeFormatRelative
 )
 
 // NewHandler creates a log [Handler] that writes to output, using the given options.
@@ -80,11 +158,18 @@ func NewHandler(output io.Writer, options *Options) *Handler {
 		preformattedGroups:          nil,
 		preformattedGroupsWithAttrs: nil,
 		indent:                      0,
+		startTime:                   time.Time{},
 	}
 	if options != nil {
 		handler.options = *options
 	}
 
+	if handler.options.Clock != nil {
+		handler.startTime = handler.options.Clock.Now()
+	} else {
+		handler.startTime = time.Now()
+	}
+
 	if handler.options.ForceColors {
 		handler.options.DisableColors = false
 	} else if !handler.options.DisableColors && !IsColorTerminal(output) {
@@ -117,7 +202,12 @@ func (handler *Handler) Handle(_ context.Context, record slog.Record) error {
 	handler.writeByteWithColor(buffer, ':', colorGray)
 	buffer.writeByte(' ')
 
-	buffer.writeString(record.Message)
+	messageTemplate, hasMessageTemplate := getMessageTemplate(record)
+	if hasMessageTemplate && !handler.options.DisableColors {
+		handler.writeMessageTemplate(buffer, messageTemplate, record)
+	} else {
+		buffer.writeString(record.Message)
+	}
 	buffer.writeByte('\n')
 
 	// Preformatted groups that have preformatted attributes: we always want to write these, so that
@@ -126,15 +216,30 @@ func (handler *Handler) Handle(_ context.Context, record slog.Record) error {
 
 	if record.NumAttrs() > 0 {
 		// We only want to write preformattedGroups (without preformatted attrs) if the current
-		// record has attributes - otherwise we end up with writing groups with no attributes
+		// record has attributes - otherwise we end up with writing groups with no attributes. The
+		// record's attributes may also all be empty (e.g. a LogValuer that resolves to an empty
+		// group), so we remove the groups again if no attributes were written.
+		lengthBeforeGroups := len(*buffer)
 		buffer.join(handler.preformattedGroups)
 
+		wroteAttrs := false
 		record.Attrs(
 			func(attr slog.Attr) bool {
-				handler.writeAttribute(buffer, attr, handler.indent)
+				// The message template is already shown in the message, so we don't repeat it
+				if hasMessageTemplate && attr.Key == messageTemplateAttrKey {
+					return true
+				}
+
+				if handler.writeAttribute(buffer, attr, handler.indent) {
+					wroteAttrs = true
+				}
 				return true
 			},
 		)
+
+		if !wroteAttrs {
+			buffer.truncate(lengthBeforeGroups)
+		}
 	}
 
 	// write preformatted attributes last, so they are shown beneath the current record's attributes
@@ -144,6 +249,10 @@ func (handler *Handler) Handle(_ context.Context, record slog.Record) error {
 		handler.writeLogSource(buffer, record.PC)
 	}
 
+	if handler.options.MaxRecordSize > 0 && len(*buffer) > handler.options.MaxRecordSize {
+		handler.truncateRecord(buffer, handler.options.MaxRecordSize)
+	}
+
 	handler.outputLock.Lock()
 	defer handler.outputLock.Unlock()
 	_, err := handler.output.Write(*buffer)
@@ -165,6 +274,11 @@ func (handler *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	for _, attr := range attrs {
 		newHandler.writeAttribute(&newHandler.preformattedAttrs, attr, newHandler.indent)
 	}
+	// If all the given attributes were empty, we don't want to move preformattedGroups below, since
+	// that would write the groups without any attributes
+	if len(newHandler.preformattedAttrs) == 0 {
+		return handler
+	}
 	newHandler.preformattedAttrs.join(handler.preformattedAttrs)
 
 	// We want to move previous preformattedGroups to preformattedGroupsWithAttrs, so we always
@@ -211,6 +325,9 @@ func (handler *Handler) writeTime(buffer *byteBuffer, time time.Time) {
 	switch handler.options.TimeFormat {
 	case TimeFormatFull:
 		buffer.writeDateTime(time)
+	case TimeFormatRelative:
+		buffer.writeByte('+')
+		buffer.writeElapsedTime(time.Sub(handler.startTime))
 	case TimeFormatShort:
 		fallthrough
 	default:
@@ -244,46 +361,85 @@ func (handler *Handler) writeLevel(buffer *byteBuffer, level slog.Level) {
 	handler.resetColor(buffer)
 }
 
-func (handler *Handler) writeAttribute(buffer *byteBuffer, attr slog.Attr, indent int) {
+// Writes the given attribute to the buffer, and returns false if nothing was written (following the
+// slog conventions of ignoring empty attributes and empty groups).
+func (handler *Handler) writeAttribute(buffer *byteBuffer, attr slog.Attr, indent int) bool {
+	// Checks for diffs from log.Diff and tables from log.Table before resolving, since they resolve
+	// to plain values
+	if attr.Value.Kind() == slog.KindLogValuer {
+		switch value := attr.Value.Any().(type) {
+		case diffValuer:
+			return handler.writeDiff(buffer, attr.Key, value.DiffChanges(), indent)
+		case tableValuer:
+			start := len(*buffer)
+			buffer.writeIndent(indent)
+			handler.writeAttributeKey(buffer, attr.Key)
+			if handler.writeTable(buffer, value.TableRows(), indent) {
+				return true
+			}
+			// If the rows can't be shown as a table, we fall back to the resolved value below
+			buffer.truncate(start)
+		}
+	}
+
 	attr.Value = attr.Value.Resolve()
 	if attr.Equal(slog.Attr{}) { //nolint:exhaustruct // Checking empty attr on purpose
-		return
+		return false
 	}
 
-	buffer.writeIndent(indent)
+	if attr.Value.Kind() == slog.KindGroup {
+		return handler.writeGroupAttribute(buffer, attr, indent)
+	}
 
-	switch attr.Value.Kind() {
-	case slog.KindGroup:
-		attrs := attr.Value.Group()
-		if len(attrs) == 0 {
-			return
-		}
+	buffer.writeIndent(indent)
 
-		if attr.Key != "" {
-			handler.writeAttributeKey(buffer, attr.Key)
-			buffer.writeByte('\n')
-			indent++
-		}
+	if rendered, ok := handler.renderCustomValue(attr.Value); ok {
+		handler.writeAttributeKey(buffer, attr.Key)
+		buffer.writeByte(' ')
+		buffer.writeString(rendered)
+		buffer.writeByte('\n')
+		return true
+	}
 
-		for _, groupAttr := range attrs {
-			handler.writeAttribute(buffer, groupAttr, indent)
-		}
+	switch attr.Value.Kind() {
 	case slog.KindTime:
 		handler.writeAttributeKey(buffer, attr.Key)
 		buffer.writeByte(' ')
-		buffer.writeDateTime(attr.Value.Time())
+		handler.writeTimeValue(buffer, attr.Value.Time())
 		buffer.writeByte('\n')
+	case slog.KindDuration:
+		handler.writeAttributeKey(buffer, attr.Key)
+		buffer.writeByte(' ')
+		handler.writeDuration(buffer, attr.Value.Duration())
+		buffer.writeByte('\n')
+	case slog.KindString:
+		handler.writeAttributeKey(buffer, attr.Key)
+		value := attr.Value.String()
+		if isTraceOrSpanID(attr.Key, value) {
+			buffer.writeByte(' ')
+			handler.writeTraceOrSpanID(buffer, value)
+			buffer.writeByte('\n')
+		} else {
+			handler.writeStringValue(buffer, value, indent)
+		}
 	case slog.KindAny:
 		handler.writeAttributeKey(buffer, attr.Key)
 
 		value := attr.Value.Any()
+		stackTrace, isStackTrace := value.([]string)
 		if attr.Key == causeErrorAttrKey {
 			handler.writeCauseError(buffer, value, indent)
 			buffer.writeByte('\n')
+		} else if attr.Key == stackTraceAttrKey && isStackTrace {
+			handler.writeStackTrace(buffer, stackTrace, indent)
+		} else if stringValue, ok := value.(string); ok {
+			handler.writeStringValue(buffer, stringValue, indent)
+		} else if handler.options.RenderTables && isTableRows(value) &&
+			handler.writeTable(buffer, value, indent) {
+			// Table is written under the attribute key, with its own trailing newline
 		} else {
 			buffer.writeByte(' ')
-			if stringValue, ok := value.(string); ok {
-				buffer.writeString(stringValue)
+			if handler.writeKnownTypeValue(buffer, value) {
 				buffer.writeByte('\n')
 			} else {
 				// JSON encoder adds its own trailing newline, so we don't need to add it here
@@ -296,6 +452,121 @@ func (handler *Handler) writeAttribute(buffer *byteBuffer, attr slog.Attr, inden
 		buffer.writeString(attr.Value.String())
 		buffer.writeByte('\n')
 	}
+
+	return true
+}
+
+// Expects the given attribute's value to be a resolved group.
+func (handler *Handler) writeGroupAttribute(buffer *byteBuffer, attr slog.Attr, indent int) bool {
+	attrs := attr.Value.Group()
+	if len(attrs) == 0 {
+		return false
+	}
+
+	if attr.Key == causeErrorAttrKey && isStructuredCauseError(attrs) {
+		buffer.writeIndent(indent)
+		handler.writeAttributeKey(buffer, attr.Key)
+		handler.writeStructuredCauseError(buffer, attrs, indent)
+		buffer.writeByte('\n')
+		return true
+	}
+
+	lengthBeforeGroup := len(*buffer)
+
+	// Follows the slog convention of inlining groups with empty keys
+	if attr.Key != "" {
+		buffer.writeIndent(indent)
+		handler.writeAttributeKey(buffer, attr.Key)
+		buffer.writeByte('\n')
+		indent++
+	}
+
+	wroteGroupAttrs := false
+	for _, groupAttr := range attrs {
+		if handler.writeAttribute(buffer, groupAttr, indent) {
+			wroteGroupAttrs = true
+		}
+	}
+
+	// If all attributes in the group were empty, we don't want to write the group key
+	if !wroteGroupAttrs {
+		buffer.truncate(lengthBeforeGroup)
+	}
+	return wroteGroupAttrs
+}
+
+// Returns the message template added by the message template functions in the log package (e.g.
+// log.Infot), if the record has one.
+func getMessageTemplate(record slog.Record) (messageTemplate string, found bool) {
+	if record.NumAttrs() == 0 {
+		return "", false
+	}
+
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			if attr.Key == messageTemplateAttrKey && attr.Value.Kind() == slog.KindString {
+				messageTemplate = attr.Value.String()
+				found = true
+				return false
+			}
+			return true
+		},
+	)
+	return messageTemplate, found
+}
+
+// Writes the record's message, highlighting the values that were substituted into the given message
+// template. The template is parsed in the same way as in the log package: {key} placeholders are
+// replaced by the record's attribute with the same key, unmatched placeholders are left as-is, and
+// "{{" and "}}" are unescaped to "{" and "}".
+//
+// If the result does not match the record's message (for example, if the message was built from a
+// different set of attributes), we write the message as-is instead.
+func (handler *Handler) writeMessageTemplate(
+	buffer *byteBuffer,
+	template string,
+	record slog.Record,
+) {
+	startLength := len(*buffer)
+	// The message without colors, to compare against the record's message
+	var message strings.Builder
+
+	messagetemplate.Parse(
+		template,
+		func(part messagetemplate.Part) {
+			if part.Key != "" {
+				if value, ok := findRecordAttr(record, part.Key); ok {
+					valueString := value.String()
+					handler.setColor(buffer, colorCyan)
+					buffer.writeString(valueString)
+					handler.resetColor(buffer)
+					message.WriteString(valueString)
+					return
+				}
+			}
+			buffer.writeString(part.Text)
+			message.WriteString(part.Text)
+		},
+	)
+
+	if message.String() != record.Message {
+		buffer.truncate(startLength)
+		buffer.writeString(record.Message)
+	}
+}
+
+func findRecordAttr(record slog.Record, key string) (value slog.Value, found bool) {
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			if attr.Key == key {
+				value = attr.Value.Resolve()
+				found = true
+				return false
+			}
+			return true
+		},
+	)
+	return value, found
 }
 
 func (handler *Handler) writeAttributeKey(buffer *byteBuffer, attrKey string) {
@@ -317,6 +588,12 @@ var jsonColors = jsoncolor.Colors{
 }
 
 func (handler *Handler) writeJSON(buffer *byteBuffer, jsonValue any, indent int) {
+	if valueLimits := handler.valueLimits(); valueLimits.LimitsJSON() {
+		if handler.writeLimitedJSON(buffer, jsonValue, valueLimits, indent) {
+			return
+		}
+	}
+
 	encoder := jsoncolor.NewEncoder(buffer)
 
 	var prefix strings.Builder
@@ -345,12 +622,199 @@ func (handler *Handler) writeCauseError(buffer *byteBuffer, errorLogValue any, i
 		for _, errorItem := range errorLogValue {
 			handler.writeCauseError(buffer, errorItem, indent)
 		}
+	case map[string]any:
+		// The log package uses a map for error items when configured to include error type/code
+		message, isErrorItem := errorLogValue[errorMessageKey].(string)
+		if !isErrorItem {
+			handler.writeListItemPrefix(buffer, indent)
+			handler.writeJSON(buffer, errorLogValue, indent)
+			return
+		}
+
+		handler.writeListItemPrefix(buffer, indent)
+		buffer.writeString(message)
+		handler.writeErrorTypeAndCode(
+			buffer,
+			errorLogValue[errorTypeKey],
+			errorLogValue[errorCodeKey],
+		)
+		if details, ok := errorLogValue[errorDetailsKey].(map[string]any); ok {
+			handler.writeErrorDetails(buffer, mapToAttrs(details), indent)
+		}
+	case slog.LogValuer:
+		// The log package uses a list of slog.LogValuers when logging multiple errors with
+		// ErrorOptions.StructuredCause enabled
+		if attrs, ok := resolveStructuredCauseError(errorLogValue); ok {
+			handler.writeStructuredCauseErrorChain(buffer, attrs, indent, true)
+		} else {
+			handler.writeListItemPrefix(buffer, indent)
+			handler.writeJSON(buffer, errorLogValue, indent)
+		}
 	default:
 		handler.writeListItemPrefix(buffer, indent)
 		handler.writeJSON(buffer, errorLogValue, indent)
 	}
 }
 
+// Writes the tree format that the log package uses for the 'cause' attribute when
+// ErrorOptions.StructuredCause is enabled, where each error is a group with a message and a list of
+// causes. We display this the same way as the default nested list format in writeCauseError: a
+// chain of wrapped errors is flattened, and errors that wrap multiple errors are shown as a nested
+// list.
+func (handler *Handler) writeStructuredCauseError(
+	buffer *byteBuffer,
+	causeErrorAttrs []slog.Attr,
+	indent int,
+) {
+	cause := parseStructuredCauseError(causeErrorAttrs)
+	if len(cause.causes) == 0 {
+		handler.writeListItemPrefix(buffer, indent)
+		buffer.writeString(cause.message)
+		handler.writeErrorTypeAndCode(buffer, cause.errorType, cause.errorCode)
+		handler.writeErrorDetails(buffer, cause.details, indent)
+		return
+	}
+
+	handler.writeStructuredCauseErrorChain(buffer, causeErrorAttrs, indent+1, false)
+}
+
+func (handler *Handler) writeStructuredCauseErrorChain(
+	buffer *byteBuffer,
+	causeErrorAttrs []slog.Attr,
+	indent int,
+	partOfList bool,
+) {
+	cause := parseStructuredCauseError(causeErrorAttrs)
+
+	handler.writeListItemPrefix(buffer, indent)
+	buffer.writeString(cause.message)
+	handler.writeErrorTypeAndCode(buffer, cause.errorType, cause.errorCode)
+	handler.writeErrorDetails(buffer, cause.details, indent)
+
+	// A single wrapped error continues the chain on the same level, unless we're in a list (in
+	// which case we nest it under its wrapper, to distinguish it from the other list items)
+	if len(cause.causes) == 1 && !partOfList {
+		handler.writeWrappedStructuredCauseError(buffer, cause.causes[0], indent, false)
+		return
+	}
+
+	for _, wrappedCause := range cause.causes {
+		handler.writeWrappedStructuredCauseError(buffer, wrappedCause, indent+1, true)
+	}
+}
+
+func (handler *Handler) writeWrappedStructuredCauseError(
+	buffer *byteBuffer,
+	wrappedCause any,
+	indent int,
+	partOfList bool,
+) {
+	if attrs, ok := resolveStructuredCauseError(wrappedCause); ok {
+		handler.writeStructuredCauseErrorChain(buffer, attrs, indent, partOfList)
+	} else {
+		handler.writeListItemPrefix(buffer, indent)
+		handler.writeJSON(buffer, wrappedCause, indent)
+	}
+}
+
+type structuredCauseError struct {
+	message   string
+	errorType any
+	errorCode any
+	details   []slog.Attr
+	causes    []any
+}
+
+func parseStructuredCauseError(causeErrorAttrs []slog.Attr) structuredCauseError {
+	var cause structuredCauseError
+	for _, attr := range causeErrorAttrs {
+		switch attr.Key {
+		case errorMessageKey:
+			cause.message = attr.Value.String()
+		case errorTypeKey:
+			cause.errorType = attr.Value.Any()
+		case errorCodeKey:
+			cause.errorCode = attr.Value.Any()
+		case errorDetailsKey:
+			if attr.Value.Kind() == slog.KindGroup {
+				cause.details = attr.Value.Group()
+			}
+		case errorCausesKey:
+			cause.causes, _ = attr.Value.Any().([]any)
+		}
+	}
+	return cause
+}
+
+func resolveStructuredCauseError(value any) (causeErrorAttrs []slog.Attr, ok bool) {
+	resolved := slog.AnyValue(value).Resolve()
+	if resolved.Kind() != slog.KindGroup {
+		return nil, false
+	}
+
+	attrs := resolved.Group()
+	if !isStructuredCauseError(attrs) {
+		return nil, false
+	}
+	return attrs, true
+}
+
+// The log package always puts the message first in structured cause errors.
+func isStructuredCauseError(attrs []slog.Attr) bool {
+	return len(attrs) != 0 && attrs[0].Key == errorMessageKey
+}
+
+// Writes the type and code of an error item in parentheses after the error message, in a dimmed
+// color so they don't draw attention away from the message.
+func (handler *Handler) writeErrorTypeAndCode(buffer *byteBuffer, errorType any, errorCode any) {
+	if errorType == nil && errorCode == nil {
+		return
+	}
+
+	handler.setColor(buffer, colorGray)
+	buffer.writeString(" (")
+	if errorType != nil {
+		buffer.writeAny(errorType)
+		if errorCode != nil {
+			buffer.writeString(", ")
+		}
+	}
+	if errorCode != nil {
+		buffer.writeString("code: ")
+		buffer.writeAny(errorCode)
+	}
+	buffer.writeByte(')')
+	handler.resetColor(buffer)
+}
+
+// Writes details of an error item (from error formatters in the log package) as attributes on the
+// lines below the error message, aligned with the message.
+func (handler *Handler) writeErrorDetails(buffer *byteBuffer, details []slog.Attr, indent int) {
+	for _, attr := range details {
+		buffer.writeByte('\n')
+		handler.writeAttribute(buffer, attr, indent+1)
+		// writeAttribute adds a trailing newline, but the list item prefix for the next error item
+		// adds its own leading newline
+		buffer.trimTrailingNewline()
+	}
+}
+
+// The log package uses a map for error details in the default cause format. We sort the keys, to
+// get consistent output.
+func mapToAttrs(attrMap map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(attrMap))
+	for key := range attrMap {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, attrMap[key]))
+	}
+	return attrs
+}
+
 func (handler *Handler) writeListItemPrefix(buffer *byteBuffer, indent int) {
 	if indent == 0 {
 		buffer.writeByte(' ')
@@ -363,6 +827,60 @@ func (handler *Handler) writeListItemPrefix(buffer *byteBuffer, indent int) {
 	buffer.writeByte(' ')
 }
 
+// Writes the stack trace that the log package adds to logs of recovered panics, where each frame is
+// formatted as "function (file:line)". We write one frame per line, and dim the file name so that
+// the function names are easier to scan.
+func (handler *Handler) writeStackTrace(buffer *byteBuffer, stackTrace []string, indent int) {
+	for _, frame := range stackTrace {
+		handler.writeListItemPrefix(buffer, indent+1)
+
+		function, file, hasFile := strings.Cut(frame, " (")
+		buffer.writeString(function)
+		if hasFile {
+			handler.setColor(buffer, colorGray)
+			buffer.writeString(" (")
+			buffer.writeString(file)
+			handler.resetColor(buffer)
+		}
+	}
+	buffer.writeByte('\n')
+}
+
+// Trace and span IDs (added by the devlog/log/otellog package) are long hex strings that are mostly
+// useful for looking up traces, so we shorten and dim them to not take focus away from the rest of
+// the log. The first 8 characters are typically enough to tell IDs apart, like short Git hashes.
+func (handler *Handler) writeTraceOrSpanID(buffer *byteBuffer, id string) {
+	handler.setColor(buffer, colorGray)
+	buffer.writeString(id[:shortTraceOrSpanIDLength])
+	handler.resetColor(buffer)
+}
+
+// OpenTelemetry trace IDs are 16 bytes and span IDs are 8 bytes, encoded as lowercase hex. We only
+// shorten values on this format, so that attributes from other sources with the same keys are
+// written as normal.
+func isTraceOrSpanID(key string, value string) bool {
+	var expectedLength int
+	switch key {
+	case traceIDAttrKey:
+		expectedLength = 32
+	case spanIDAttrKey:
+		expectedLength = 16
+	default:
+		return false
+	}
+
+	if len(value) != expectedLength {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		char := value[i]
+		if (char < '0' || char > '9') && (char < 'a' || char > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 func (handler *Handler) writeLogSource(buffer *byteBuffer, programCounter uintptr) {
 	frames := runtime.CallersFrames([]uintptr{programCounter})
 	frame, _ := frames.Next()
@@ -405,6 +923,21 @@ func (handler *Handler) writeLogSource(buffer *byteBuffer, programCounter uintpt
 	buffer.writeByte('\n')
 }
 
-// Should be the same key as in log/errors.go (we don't import this across packages, as that would
-// require a dependency between them, whereas they're currently independent from each other).
-const causeErrorAttrKey = "cause"
+// Should be the same keys as in log/errors.go, log/panic.go and log/otellog (we don't import this
+// across packages, as that would require a dependency between them, whereas they're currently
+// independent from each other).
+const (
+	causeErrorAttrKey = "cause"
+	errorMessageKey   = "message"
+	errorTypeKey      = "type"
+	errorCodeKey      = "code"
+	errorDetailsKey   = "details"
+	errorCausesKey    = "causes"
+	stackTraceAttrKey = "stack"
+	traceIDAttrKey    = "trace_id"
+	spanIDAttrKey     = "span_id"
+	// Added by the message template functions in the log package (e.g. log.Infot).
+	messageTemplateAttrKey = "messageTemplate"
+)
+
+const shortTraceOrSpanIDLength = 8
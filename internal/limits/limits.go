@@ -0,0 +1,261 @@
+// Package limits implements truncation of large log attribute values. It's shared by the devlog
+// handler (see devlog.Options.MaxStringLength etc.) and log.LimitHandler, so that both truncate
+// values in the same way.
+package limits
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Limits for attribute values. A limit of 0 or less means no limit.
+type Limits struct {
+	MaxStringLength  int
+	MaxJSONDepth     int
+	MaxArrayElements int
+}
+
+// LimitsJSON returns true if any of the limits apply to JSON values.
+func (limits Limits) LimitsJSON() bool {
+	return limits.MaxStringLength > 0 || limits.MaxJSONDepth > 0 || limits.MaxArrayElements > 0
+}
+
+// TruncateString truncates the given string to maxLength bytes (without splitting UTF-8
+// characters), and appends a marker with the size of the truncated part. Returns the string
+// unchanged if maxLength is 0 or less, or if the string is within the limit.
+func TruncateString(value string, maxLength int) string {
+	if maxLength <= 0 || len(value) <= maxLength {
+		return value
+	}
+
+	end := maxLength
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end] + TruncationMarker(len(value)-end)
+}
+
+// TruncationMarker returns a marker for a truncated part of the given size in bytes, such as
+// "…(truncated 12.3 KiB)".
+func TruncationMarker(truncatedBytes int) string {
+	return "…(truncated " + FormatByteSize(int64(truncatedBytes)) + ")"
+}
+
+var byteSizeUnits = [...]string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// FormatByteSize formats the given number of bytes with binary unit prefixes, rounded to 3
+// significant digits (such as 1.5 KiB or 12.3 MiB).
+func FormatByteSize(size int64) string {
+	value := float64(size)
+	unit := 0
+	for (value >= 1024 || value <= -1024) && unit < len(byteSizeUnits)-1 {
+		value /= 1024
+		unit++
+	}
+
+	decimals := 0
+	if unit > 0 {
+		switch absValue := max(value, -value); {
+		case absValue < 10:
+			decimals = 2
+		case absValue < 100:
+			decimals = 1
+		}
+	}
+
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+	if decimals > 0 {
+		// Trims trailing zeros, so that 1.50 KiB becomes 1.5 KiB, and 1.00 KiB becomes 1 KiB
+		for formatted[len(formatted)-1] == '0' {
+			formatted = formatted[:len(formatted)-1]
+		}
+		if formatted[len(formatted)-1] == '.' {
+			formatted = formatted[:len(formatted)-1]
+		}
+	}
+
+	return formatted + " " + byteSizeUnits[unit]
+}
+
+// LimitJSON applies the given limits to the given JSON data, returning compact JSON where:
+//   - Strings longer than MaxStringLength are truncated (see [TruncateString])
+//   - Objects and arrays nested deeper than MaxJSONDepth are replaced by a truncation marker
+//     string with their size (the top-level value has depth 1)
+//   - Arrays with more than MaxArrayElements elements are cut off, followed by a marker string
+//     with the number of truncated elements
+//
+// The order of object keys is preserved.
+func LimitJSON(data []byte, limits Limits) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	limiter := jsonLimiter{decoder: decoder, input: data, limits: limits, output: nil}
+	if err := limiter.writeValue(1); err != nil {
+		return nil, err
+	}
+	return limiter.output, nil
+}
+
+type jsonLimiter struct {
+	decoder *json.Decoder
+	input   []byte
+	limits  Limits
+	output  []byte
+}
+
+func (limiter *jsonLimiter) writeValue(depth int) error {
+	start := limiter.decoder.InputOffset()
+	token, err := limiter.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if limiter.limits.MaxJSONDepth > 0 && depth > limiter.limits.MaxJSONDepth {
+			if err := limiter.skipRest(); err != nil {
+				return err
+			}
+			// The start offset may include whitespace and separators before the value
+			value := limiter.input[start:limiter.decoder.InputOffset()]
+			size := len(value) - bytes.IndexAny(value, "{[")
+			return limiter.writeString(TruncationMarker(size))
+		}
+
+		if token == '{' {
+			return limiter.writeObject(depth)
+		}
+		return limiter.writeArray(depth)
+	case string:
+		return limiter.writeString(TruncateString(token, limiter.limits.MaxStringLength))
+	case json.Number:
+		limiter.output = append(limiter.output, token...)
+	case bool:
+		limiter.output = strconv.AppendBool(limiter.output, token)
+	case nil:
+		limiter.output = append(limiter.output, "null"...)
+	}
+
+	return nil
+}
+
+// Expects the opening '{' to have been consumed.
+func (limiter *jsonLimiter) writeObject(depth int) error {
+	limiter.output = append(limiter.output, '{')
+
+	first := true
+	for limiter.decoder.More() {
+		key, err := limiter.decoder.Token()
+		if err != nil {
+			return err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return errors.New("expected string key in JSON object")
+		}
+
+		if !first {
+			limiter.output = append(limiter.output, ',')
+		}
+		first = false
+
+		if err := limiter.writeString(keyString); err != nil {
+			return err
+		}
+		limiter.output = append(limiter.output, ':')
+		if err := limiter.writeValue(depth + 1); err != nil {
+			return err
+		}
+	}
+
+	// Consumes the closing '}'
+	if _, err := limiter.decoder.Token(); err != nil {
+		return err
+	}
+	limiter.output = append(limiter.output, '}')
+	return nil
+}
+
+// Expects the opening '[' to have been consumed.
+func (limiter *jsonLimiter) writeArray(depth int) error {
+	limiter.output = append(limiter.output, '[')
+
+	maxElements := limiter.limits.MaxArrayElements
+	count := 0
+	for limiter.decoder.More() {
+		if maxElements > 0 && count >= maxElements {
+			if err := limiter.skipValue(); err != nil {
+				return err
+			}
+		} else {
+			if count > 0 {
+				limiter.output = append(limiter.output, ',')
+			}
+			if err := limiter.writeValue(depth + 1); err != nil {
+				return err
+			}
+		}
+		count++
+	}
+
+	if maxElements > 0 && count > maxElements {
+		limiter.output = append(limiter.output, ',')
+		truncatedElements := count - maxElements
+		marker := "…(truncated " + strconv.Itoa(truncatedElements) + " elements)"
+		if truncatedElements == 1 {
+			marker = "…(truncated 1 element)"
+		}
+		if err := limiter.writeString(marker); err != nil {
+			return err
+		}
+	}
+
+	// Consumes the closing ']'
+	if _, err := limiter.decoder.Token(); err != nil {
+		return err
+	}
+	limiter.output = append(limiter.output, ']')
+	return nil
+}
+
+func (limiter *jsonLimiter) writeString(value string) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	limiter.output = append(limiter.output, encoded...)
+	return nil
+}
+
+func (limiter *jsonLimiter) skipValue() error {
+	token, err := limiter.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if _, isDelim := token.(json.Delim); isDelim {
+		return limiter.skipRest()
+	}
+	return nil
+}
+
+// Skips the rest of an object or array, after its opening delimiter has been consumed.
+func (limiter *jsonLimiter) skipRest() error {
+	nesting := 1
+	for nesting > 0 {
+		token, err := limiter.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, isDelim := token.(json.Delim); isDelim {
+			if delim == '{' || delim == '[' {
+				nesting++
+			} else {
+				nesting--
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package limits_test
+
+import (
+	"testing"
+
+	"hermannm.dev/devlog/internal/limits"
+)
+
+func TestTruncateString(t *testing.T) {
+	for _, testCase := range []struct {
+		value     string
+		maxLength int
+		expected  string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 7, "this is…(truncated 9 B)"},
+		{"no limit", 0, "no limit"},
+		// Should not split multi-byte characters (æ is 2 bytes)
+		{"blåbærsyltetøy", 6, "blåb…(truncated 12 B)"},
+	} {
+		actual := limits.TruncateString(testCase.value, testCase.maxLength)
+		if actual != testCase.expected {
+			t.Errorf("Unexpected truncated string\nWant: %q\n Got: %q", testCase.expected, actual)
+		}
+	}
+}
+
+func TestLimitJSON(t *testing.T) {
+	for _, testCase := range []struct {
+		name     string
+		input    string
+		limits   limits.Limits
+		expected string
+	}{
+		{
+			name:     "no limits",
+			input:    `{"b": [1, 2.5, "3"], "a": {"c": null, "d": true}}`,
+			limits:   limits.Limits{MaxStringLength: 0, MaxJSONDepth: 0, MaxArrayElements: 0},
+			expected: `{"b":[1,2.5,"3"],"a":{"c":null,"d":true}}`,
+		},
+		{
+			name:     "string length",
+			input:    `{"key": "long value", "nested": ["another long value"]}`,
+			limits:   limits.Limits{MaxStringLength: 4, MaxJSONDepth: 0, MaxArrayElements: 0},
+			expected: `{"key":"long…(truncated 6 B)","nested":["anot…(truncated 14 B)"]}`,
+		},
+		{
+			name:     "JSON depth",
+			input:    `{"a": {"b": {"c": 1}}, "d": [[1, 2], 3], "e": 4}`,
+			limits:   limits.Limits{MaxStringLength: 0, MaxJSONDepth: 2, MaxArrayElements: 0},
+			expected: `{"a":{"b":"…(truncated 8 B)"},"d":["…(truncated 6 B)",3],"e":4}`,
+		},
+		{
+			name:     "array elements",
+			input:    `[1, [2, 3, 4], {"a": 5}, 6, 7]`,
+			limits:   limits.Limits{MaxStringLength: 0, MaxJSONDepth: 0, MaxArrayElements: 2},
+			expected: `[1,[2,3,"…(truncated 1 element)"],"…(truncated 3 elements)"]`,
+		},
+	} {
+		t.Run(
+			testCase.name,
+			func(t *testing.T) {
+				actual, err := limits.LimitJSON([]byte(testCase.input), testCase.limits)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(actual) != testCase.expected {
+					t.Errorf(
+						"Unexpected limited JSON\nWant: %s\n Got: %s",
+						testCase.expected,
+						actual,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestLimitJSONInvalid(t *testing.T) {
+	if _, err := limits.LimitJSON([]byte(`{"key": `), limits.Limits{}); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
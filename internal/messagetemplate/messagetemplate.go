@@ -0,0 +1,56 @@
+// Package messagetemplate implements parsing of message templates, shared by the message template
+// log functions in the log package (see log.Infot) and the devlog handler (which highlights the
+// values substituted into the message), so that both parse templates in the same way.
+package messagetemplate
+
+import (
+	"strings"
+)
+
+// Part is a part of a message template: either literal text, or a {key} placeholder.
+type Part struct {
+	// Literal text (with "{{" and "}}" unescaped to "{" and "}"), or the placeholder as written in
+	// the template (including braces), for placeholders.
+	Text string
+	// Key of the placeholder, or blank for literal text.
+	Key string
+}
+
+// Parse splits the given template into parts, and calls handlePart for each of them in order.
+// Placeholders are on the form {key}, and "{{" and "}}" are escaped braces. Lone braces and
+// placeholders with empty keys are treated as literal text.
+//
+// Callers should write a placeholder's value if they find a value for its key, and otherwise write
+// its Text as-is.
+func Parse(template string, handlePart func(part Part)) {
+	for len(template) > 0 {
+		index := strings.IndexAny(template, "{}")
+		if index == -1 {
+			handlePart(Part{Text: template, Key: ""})
+			return
+		}
+
+		if index > 0 {
+			handlePart(Part{Text: template[:index], Key: ""})
+			template = template[index:]
+		}
+
+		if strings.HasPrefix(template, "{{") || strings.HasPrefix(template, "}}") {
+			handlePart(Part{Text: template[:1], Key: ""})
+			template = template[2:]
+			continue
+		}
+
+		if template[0] == '{' {
+			if end := strings.IndexAny(template[1:], "{}"); end > 0 && template[end+1] == '}' {
+				handlePart(Part{Text: template[:end+2], Key: template[1 : end+1]})
+				template = template[end+2:]
+				continue
+			}
+		}
+
+		// Lone brace
+		handlePart(Part{Text: template[:1], Key: ""})
+		template = template[1:]
+	}
+}
@@ -0,0 +1,56 @@
+package messagetemplate_test
+
+import (
+	"slices"
+	"testing"
+
+	"hermannm.dev/devlog/internal/messagetemplate"
+)
+
+func TestParse(t *testing.T) {
+	type part = messagetemplate.Part
+
+	testCases := []struct {
+		template      string
+		expectedParts []part
+	}{
+		{"No placeholders", []part{{Text: "No placeholders", Key: ""}}},
+		{
+			"User {userId} logged in",
+			[]part{
+				{Text: "User ", Key: ""},
+				{Text: "{userId}", Key: "userId"},
+				{Text: " logged in", Key: ""},
+			},
+		},
+		{
+			"{{escaped}}",
+			[]part{{Text: "{", Key: ""}, {Text: "escaped", Key: ""}, {Text: "}", Key: ""}},
+		},
+		{
+			"{} {unclosed",
+			[]part{
+				{Text: "{", Key: ""},
+				{Text: "}", Key: ""},
+				{Text: " ", Key: ""},
+				{Text: "{", Key: ""},
+				{Text: "unclosed", Key: ""},
+			},
+		},
+		{"{a}{b}", []part{{Text: "{a}", Key: "a"}, {Text: "{b}", Key: "b"}}},
+	}
+
+	for _, testCase := range testCases {
+		var parts []part
+		messagetemplate.Parse(testCase.template, func(part part) { parts = append(parts, part) })
+
+		if !slices.Equal(parts, testCase.expectedParts) {
+			t.Errorf(
+				"Unexpected parts for template %q\nWant: %+v\n Got: %+v",
+				testCase.template,
+				testCase.expectedParts,
+				parts,
+			)
+		}
+	}
+}
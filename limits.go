@@ -0,0 +1,162 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+
+	"hermannm.dev/devlog/internal/limits"
+)
+
+func (handler *Handler) valueLimits() limits.Limits {
+	return limits.Limits{
+		MaxStringLength:  handler.options.MaxStringLength,
+		MaxJSONDepth:     handler.options.MaxJSONDepth,
+		MaxArrayElements: handler.options.MaxArrayElements,
+	}
+}
+
+// Marshals the given value to JSON, applies the given limits to it, and writes it with the same
+// indentation and colors as writeJSON. Returns false if the value could not be marshaled.
+//
+// We can't pass the limited JSON to the jsoncolor encoder in writeJSON as a [json.RawMessage],
+// since it decodes raw messages to maps, which loses the order of object keys.
+func (handler *Handler) writeLimitedJSON(
+	buffer *byteBuffer,
+	value any,
+	valueLimits limits.Limits,
+	indent int,
+) bool {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	limitedData, err := limits.LimitJSON(data, valueLimits)
+	if err != nil {
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(limitedData))
+	decoder.UseNumber()
+
+	lengthBefore := len(*buffer)
+	if err := handler.writeJSONValue(buffer, decoder, indent); err != nil {
+		buffer.truncate(lengthBefore)
+		return false
+	}
+	buffer.writeByte('\n')
+	return true
+}
+
+// Writes the next JSON value from the given decoder, with object members and array elements
+// indented one level deeper than the given indent.
+func (handler *Handler) writeJSONValue(
+	buffer *byteBuffer,
+	decoder *json.Decoder,
+	indent int,
+) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		isObject := token == '{'
+		closing := byte(']')
+		if isObject {
+			closing = '}'
+		}
+
+		handler.writeByteWithColor(buffer, byte(token), colorGray)
+		empty := true
+		for decoder.More() {
+			if !empty {
+				handler.writeByteWithColor(buffer, ',', colorGray)
+			}
+			buffer.writeByte('\n')
+			buffer.writeIndent(indent + 1)
+
+			if isObject {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				handler.setColor(buffer, colorCyan)
+				writeJSONString(buffer, key)
+				handler.resetColor(buffer)
+				handler.writeByteWithColor(buffer, ':', colorGray)
+				buffer.writeByte(' ')
+			}
+
+			if err := handler.writeJSONValue(buffer, decoder, indent+1); err != nil {
+				return err
+			}
+			empty = false
+		}
+
+		// Consumes the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		if !empty {
+			buffer.writeByte('\n')
+			buffer.writeIndent(indent)
+		}
+		handler.writeByteWithColor(buffer, closing, colorGray)
+	case string:
+		writeJSONString(buffer, token)
+	case json.Number:
+		buffer.writeString(token.String())
+	case bool:
+		buffer.writeAny(token)
+	case nil:
+		buffer.writeString("null")
+	}
+
+	return nil
+}
+
+func writeJSONString(buffer *byteBuffer, value any) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		buffer.writeAny(value)
+		return
+	}
+	buffer.write(encoded)
+}
+
+// Cuts off the record output in the given buffer at the last full line within maxSize, and writes
+// a truncation marker with the size of the truncated part. If the first line is longer than
+// maxSize, it is cut off in the middle (but not in the middle of a UTF-8 character or color escape
+// code).
+func (handler *Handler) truncateRecord(buffer *byteBuffer, maxSize int) {
+	cutoff := bytes.LastIndexByte((*buffer)[:maxSize], '\n') + 1
+	cutMidLine := cutoff == 0
+	if cutMidLine {
+		cutoff = maxSize
+		for cutoff > 0 && !utf8.RuneStart((*buffer)[cutoff]) {
+			cutoff--
+		}
+		// We don't want to cut off in the middle of a color escape code, since that would leave an
+		// invalid escape sequence in the output. So if the last escape code before the cutoff is
+		// not terminated, we cut off before it.
+		if escapeIndex := bytes.LastIndexByte((*buffer)[:cutoff], '\x1b'); escapeIndex != -1 &&
+			bytes.IndexByte((*buffer)[escapeIndex:cutoff], 'm') == -1 {
+			cutoff = escapeIndex
+		}
+	}
+	truncatedBytes := len(*buffer) - cutoff
+	buffer.truncate(cutoff)
+
+	if cutMidLine {
+		// We may have cut off in the middle of a colored value, so we reset the color
+		handler.resetColor(buffer)
+		buffer.writeByte('\n')
+	}
+	buffer.writeIndent(0)
+	handler.setColor(buffer, colorGray)
+	buffer.writeString(limits.TruncationMarker(truncatedBytes))
+	handler.resetColor(buffer)
+	buffer.writeByte('\n')
+}
@@ -0,0 +1,258 @@
+// Package baggage passes context attributes from [log.AddContextAttrs] across process boundaries,
+// using the [W3C Baggage] format. This lets you correlate logs between services, for example by
+// propagating a user ID from the service that received a request to the services it calls.
+//
+// On the sending side, use [Inject] to add context attributes to the headers of an outgoing
+// request:
+//
+//	options := &baggage.Options{AllowedKeys: []string{"userId", "tenant"}}
+//
+//	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	// ...
+//	baggage.Inject(ctx, req.Header, options)
+//
+// On the receiving side, use [Extract] to add them to the request context (or set
+// httplog.Options.PropagatedAttrs, which does this for you):
+//
+//	ctx := baggage.Extract(req.Context(), req.Header, options)
+//
+// Only attributes with keys in [Options.AllowedKeys] are propagated, on both the sending and the
+// receiving side. This makes sure that you don't leak sensitive attributes to other services, and
+// that clients can't add arbitrary attributes to your logs.
+//
+// [W3C Baggage]: https://www.w3.org/TR/baggage/
+package baggage
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"hermannm.dev/devlog/log"
+)
+
+// Options configure which context attributes are propagated, and how much.
+type Options struct {
+	// AllowedKeys lists the keys of context attributes that should be propagated. Attributes with
+	// other keys are ignored when encoding and decoding. If empty, no attributes are propagated.
+	AllowedKeys []string
+
+	// MaxValueLength is the maximum length of an attribute value (before encoding). Attributes
+	// with longer values are skipped, rather than truncated, since a truncated ID would be
+	// misleading.
+	// If 0, defaults to 256.
+	MaxValueLength int
+
+	// MaxSize is the maximum total size in bytes of encoded baggage (including any existing
+	// baggage in the header, when using [Inject]). Attributes that would exceed the limit are
+	// skipped.
+	// If 0, defaults to 8192, the limit set by the W3C Baggage specification.
+	MaxSize int
+}
+
+// Inject encodes allowed context attributes from the given context (see [Encode]), and adds them
+// to the "baggage" header. If the header already has baggage (for example from an OpenTelemetry
+// propagator), then the attributes are appended to the existing baggage, unless the existing
+// baggage already has an entry with the same key.
+//
+// If options is nil, no attributes are propagated.
+func Inject(ctx context.Context, header http.Header, options *Options) {
+	existing := header.Values(baggageHeader)
+	encoded := encode(ctx, options, existing)
+	if encoded == "" {
+		return
+	}
+
+	if len(existing) == 0 {
+		header.Set(baggageHeader, encoded)
+	} else {
+		header.Set(baggageHeader, strings.Join(existing, ",")+","+encoded)
+	}
+}
+
+// Extract decodes allowed attributes from the "baggage" header (see [Decode]), and returns a copy
+// of the given parent context with the attributes added by [log.AddContextAttrs].
+//
+// If options is nil, no attributes are propagated, and the parent context is returned as-is.
+func Extract(parent context.Context, header http.Header, options *Options) context.Context {
+	return decode(parent, header.Values(baggageHeader), options)
+}
+
+// Encode returns the context attributes in the given context that have keys in
+// [Options.AllowedKeys], encoded in the W3C Baggage format:
+//
+//	userId=1234,tenant=Acme%20Corp
+//
+// Values are encoded with [slog.Value.String], so they are received as string attributes on the
+// other side. Group attributes are not supported, and are skipped. Attributes that exceed the
+// limits in [Options] are also skipped.
+//
+// If no attributes are encoded, it returns a blank string. If options is nil, no attributes are
+// propagated.
+func Encode(ctx context.Context, options *Options) string {
+	return encode(ctx, options, nil)
+}
+
+// Decode parses the given value in the W3C Baggage format (see [Encode]), and returns a copy of
+// the given parent context with the attributes that have keys in [Options.AllowedKeys] added by
+// [log.AddContextAttrs]. Entries that are malformed or exceed the limits in [Options] are
+// ignored.
+//
+// If options is nil, no attributes are propagated, and the parent context is returned as-is.
+func Decode(parent context.Context, baggage string, options *Options) context.Context {
+	return decode(parent, []string{baggage}, options)
+}
+
+func encode(ctx context.Context, options *Options, existingBaggage []string) string {
+	limits := getLimits(options)
+	if len(limits.AllowedKeys) == 0 {
+		return ""
+	}
+
+	size := 0
+	var existingKeys []string
+	for _, existing := range existingBaggage {
+		size += len(existing) + 1 // +1 for the comma separator
+		for _, member := range strings.Split(existing, ",") {
+			if key, _, ok := parseMember(member); ok {
+				existingKeys = append(existingKeys, key)
+			}
+		}
+	}
+
+	var builder strings.Builder
+	for _, attr := range log.ContextAttrs(ctx) {
+		if !slices.Contains(limits.AllowedKeys, attr.Key) ||
+			slices.Contains(existingKeys, attr.Key) ||
+			!isValidKey(attr.Key) {
+			continue
+		}
+
+		value := attr.Value.Resolve()
+		if value.Kind() == slog.KindGroup {
+			continue
+		}
+		stringValue := value.String()
+		if len(stringValue) > limits.MaxValueLength {
+			continue
+		}
+
+		member := attr.Key + "=" + url.PathEscape(stringValue)
+		memberSize := len(member)
+		if builder.Len() != 0 {
+			memberSize++ // For the comma separator
+		}
+		if size+builder.Len()+memberSize > limits.MaxSize {
+			continue
+		}
+
+		if builder.Len() != 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteString(member)
+	}
+
+	return builder.String()
+}
+
+func decode(parent context.Context, baggageValues []string, options *Options) context.Context {
+	limits := getLimits(options)
+	if len(limits.AllowedKeys) == 0 {
+		return parent
+	}
+
+	var attrs []any
+	var decodedKeys []string
+	size := 0
+
+BaggageLoop:
+	for _, baggage := range baggageValues {
+		for _, member := range strings.Split(baggage, ",") {
+			size += len(member)
+			if size > limits.MaxSize {
+				break BaggageLoop
+			}
+			size++ // For the comma separator
+
+			key, encodedValue, ok := parseMember(member)
+			if !ok ||
+				!slices.Contains(limits.AllowedKeys, key) ||
+				slices.Contains(decodedKeys, key) {
+				continue
+			}
+
+			value, err := url.PathUnescape(encodedValue)
+			if err != nil || len(value) > limits.MaxValueLength {
+				continue
+			}
+
+			attrs = append(attrs, slog.String(key, value))
+			decodedKeys = append(decodedKeys, key)
+		}
+	}
+
+	if len(attrs) == 0 {
+		return parent
+	}
+	return log.AddContextAttrs(parent, attrs...)
+}
+
+// Parses a baggage list member on the format "key=value;property1;property2". We don't use
+// properties, so we discard them.
+func parseMember(member string) (key string, value string, ok bool) {
+	member, _, _ = strings.Cut(member, ";")
+	key, value, ok = strings.Cut(member, "=")
+	if !ok {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(key)
+	if !isValidKey(key) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// Baggage keys must be tokens as defined by RFC 7230:
+// https://www.rfc-editor.org/rfc/rfc7230#section-3.2.6
+func isValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		char := key[i]
+		isAlphanumeric := (char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("!#$%&'*+-.^_`|~", rune(char)) {
+			return false
+		}
+	}
+	return true
+}
+
+func getLimits(options *Options) Options {
+	limits := Options{AllowedKeys: nil, MaxValueLength: 0, MaxSize: 0}
+	if options != nil {
+		limits = *options
+	}
+
+	if limits.MaxValueLength == 0 {
+		limits.MaxValueLength = defaultMaxValueLength
+	}
+	if limits.MaxSize == 0 {
+		limits.MaxSize = defaultMaxSize
+	}
+
+	return limits
+}
+
+const (
+	baggageHeader         = "Baggage"
+	defaultMaxValueLength = 256
+	defaultMaxSize        = 8192
+)
@@ -0,0 +1,122 @@
+package baggage_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/baggage"
+)
+
+func TestEncode(t *testing.T) {
+	ctx := log.AddContextAttrs(
+		context.Background(),
+		"userId", 1234,
+		"tenant", "Acme Corp",
+		"password", "secret",
+		slog.Group("group", "key", "value"),
+	)
+
+	encoded := baggage.Encode(
+		ctx,
+		&baggage.Options{AllowedKeys: []string{"userId", "tenant", "group"}},
+	)
+
+	expected := "userId=1234,tenant=Acme%20Corp"
+	if encoded != expected {
+		t.Errorf("Unexpected encoded baggage\nWant: %s\n Got: %s", expected, encoded)
+	}
+}
+
+func TestEncodeNilOptions(t *testing.T) {
+	ctx := log.AddContextAttrs(context.Background(), "userId", 1234)
+
+	if encoded := baggage.Encode(ctx, nil); encoded != "" {
+		t.Errorf("Expected no attributes to be encoded with nil options, got '%s'", encoded)
+	}
+}
+
+func TestEncodeLimits(t *testing.T) {
+	ctx := log.AddContextAttrs(
+		context.Background(),
+		"key1", "short",
+		"key2", strings.Repeat("a", 20),
+		"key3", "value3",
+		"key4", "value4",
+	)
+
+	encoded := baggage.Encode(
+		ctx,
+		&baggage.Options{
+			AllowedKeys:    []string{"key1", "key2", "key3", "key4"},
+			MaxValueLength: 10,
+			// Fits 'key1=short,key3=value3', but not 'key4=value4'
+			MaxSize: 30,
+		},
+	)
+
+	expected := "key1=short,key3=value3"
+	if encoded != expected {
+		t.Errorf("Unexpected encoded baggage\nWant: %s\n Got: %s", expected, encoded)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	ctx := baggage.Decode(
+		context.Background(),
+		"userId=1234;someProperty, tenant = Acme%20Corp ,password=secret,invalid,userId=duplicate",
+		&baggage.Options{AllowedKeys: []string{"userId", "tenant"}},
+	)
+
+	assertContextAttrs(t, ctx, slog.String("userId", "1234"), slog.String("tenant", "Acme Corp"))
+}
+
+func TestDecodeLimits(t *testing.T) {
+	ctx := baggage.Decode(
+		context.Background(),
+		"key1=short,key2="+strings.Repeat("a", 20)+",key3=value3,key4=value4",
+		&baggage.Options{
+			AllowedKeys:    []string{"key1", "key2", "key3", "key4"},
+			MaxValueLength: 10,
+			MaxSize:        len("key1=short,key2=" + strings.Repeat("a", 20) + ",key3=value3"),
+		},
+	)
+
+	assertContextAttrs(t, ctx, slog.String("key1", "short"), slog.String("key3", "value3"))
+}
+
+func TestInjectAndExtract(t *testing.T) {
+	options := &baggage.Options{AllowedKeys: []string{"userId", "tenant"}}
+
+	ctx := log.AddContextAttrs(context.Background(), "userId", 1234, "tenant", "Acme Corp")
+	header := http.Header{}
+	// Existing baggage (e.g. from OpenTelemetry) should be kept, and take precedence
+	header.Set("Baggage", "otelKey=otelValue,tenant=existing")
+
+	baggage.Inject(ctx, header, options)
+
+	expected := "otelKey=otelValue,tenant=existing,userId=1234"
+	if actual := header.Get("Baggage"); actual != expected {
+		t.Errorf("Unexpected baggage header\nWant: %s\n Got: %s", expected, actual)
+	}
+
+	extracted := baggage.Extract(context.Background(), header, options)
+	assertContextAttrs(
+		t,
+		extracted,
+		slog.String("tenant", "existing"),
+		slog.String("userId", "1234"),
+	)
+}
+
+func assertContextAttrs(t *testing.T, ctx context.Context, expected ...slog.Attr) {
+	t.Helper()
+
+	if actual := log.ContextAttrs(ctx); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected context attributes\nWant: %v\n Got: %v", expected, actual)
+	}
+}
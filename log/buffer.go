@@ -0,0 +1,307 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// BufferOptions configure the handler returned by [log.BufferHandler].
+type BufferOptions struct {
+	// TriggerLevel is the level at which a log flushes the buffer. When a log at or above this level
+	// is made in a buffered context, all previously buffered logs in that context are written to the
+	// wrapped handler, followed by the triggering log.
+	// If nil, defaults to [slog.LevelError].
+	TriggerLevel slog.Leveler
+
+	// BufferLevel is the minimum level of logs to buffer. Logs below this level are dropped, even if
+	// the buffer is flushed. This is independent of the wrapped handler's level, so that you can get
+	// DEBUG logs for failed requests while only outputting INFO logs otherwise.
+	// If nil, defaults to [slog.LevelDebug].
+	BufferLevel slog.Leveler
+
+	// MaxRecords is the maximum number of logs to keep in a buffer. If a buffer is full, the oldest
+	// log is discarded to make room for the new one.
+	// If 0 or negative, defaults to 1000.
+	MaxRecords int
+}
+
+// BufferHandler wraps a [slog.Handler], buffering logs made in a context from [log.StartBuffer].
+// Buffered logs are only written to the wrapped handler if a log at or above
+// [BufferOptions.TriggerLevel] is made in the same context. Otherwise, they are discarded when the
+// buffer's scope ends. This is sometimes called "fingers-crossed" logging: you get quiet logs for
+// things that go well, but full details for things that fail.
+//
+// Logs made in a context without a buffer are passed directly to the wrapped handler.
+//
+// Attribute values of buffered logs are resolved when the log is made (see
+// [slog.Value.Resolve]), so [slog.LogValuer] values show their state from the time of logging.
+// Other values are not copied, so you should not modify maps or values behind pointers after
+// logging them.
+//
+// Example of how to set up your handler with this:
+//
+//	logHandler := log.BufferHandler(devlog.NewHandler(os.Stdout, nil), nil)
+//	log.SetDefault(logHandler)
+//
+// Then, start a buffer in the scope that you want to buffer logs for, such as a request handler:
+//
+//	func handleRequest(res http.ResponseWriter, req *http.Request) {
+//		ctx, endBuffer := log.StartBuffer(req.Context())
+//		defer endBuffer()
+//
+//		log.Debug(ctx, "Only shown if the request fails")
+//		// ...
+//	}
+//
+// If options is nil, the default options are used (see [BufferOptions]). BufferHandler panics if
+// the given handler is nil.
+func BufferHandler(wrapped slog.Handler, options *BufferOptions) slog.Handler {
+	if wrapped == nil {
+		panic("nil slog.Handler given to BufferHandler")
+	}
+
+	handler := bufferHandler{
+		wrapped:      wrapped,
+		triggerLevel: slog.LevelError,
+		bufferLevel:  slog.LevelDebug,
+		maxRecords:   defaultMaxBufferedRecords,
+	}
+	if options != nil {
+		if options.TriggerLevel != nil {
+			handler.triggerLevel = options.TriggerLevel
+		}
+		if options.BufferLevel != nil {
+			handler.bufferLevel = options.BufferLevel
+		}
+		if options.MaxRecords > 0 {
+			handler.maxRecords = options.MaxRecords
+		}
+	}
+	return handler
+}
+
+// StartBuffer returns a copy of the given parent context with a new log buffer, along with a
+// function to end the buffer's scope. Logs made in the returned context (or its children) are
+// buffered by [log.BufferHandler], until a log at the trigger level flushes the buffer, or the end
+// function is called. After the buffer has been flushed, subsequent logs in the context are written
+// directly (down to [BufferOptions.BufferLevel]), so that you get the full details of what happened
+// after the failure as well.
+//
+// The end function discards any logs that are still buffered. Logs made in the context after that
+// are handled as if the context had no buffer. It's safe to call the end function multiple times.
+//
+// If the parent context already has a buffer, the new buffer replaces it in the returned context.
+// StartBuffer has no effect unless your log handler is wrapped with [log.BufferHandler].
+func StartBuffer(parent context.Context) (ctx context.Context, end func()) {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	//nolint:exhaustruct // Buffer starts out empty
+	buffer := &logBuffer{state: bufferStateBuffering}
+	return context.WithValue(parent, logBufferKey, buffer), buffer.end
+}
+
+type bufferHandler struct {
+	wrapped      slog.Handler
+	triggerLevel slog.Leveler
+	bufferLevel  slog.Leveler
+	maxRecords   int
+}
+
+func (handler bufferHandler) Handle(ctx context.Context, record slog.Record) error {
+	buffer := getLogBuffer(ctx)
+	if buffer == nil {
+		return handler.wrapped.Handle(ctx, record)
+	}
+
+	return buffer.handle(ctx, record, handler)
+}
+
+func (handler bufferHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	buffer := getLogBuffer(ctx)
+	if buffer != nil && buffer.isActive() && level >= handler.bufferLevel.Level() {
+		return true
+	}
+
+	return handler.wrapped.Enabled(ctx, level)
+}
+
+func (handler bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handler.wrapped = handler.wrapped.WithAttrs(attrs)
+	return handler
+}
+
+func (handler bufferHandler) WithGroup(name string) slog.Handler {
+	handler.wrapped = handler.wrapped.WithGroup(name)
+	return handler
+}
+
+type logBuffer struct {
+	lock  sync.Mutex
+	state bufferState
+	// Ring buffer of records: once it reaches the max number of records, new records overwrite the
+	// oldest one, at the start index.
+	records []bufferedRecord
+	start   int
+}
+
+type bufferState int8
+
+const (
+	bufferStateBuffering bufferState = iota
+	bufferStateFlushed
+	bufferStateEnded
+)
+
+// We store the handler along with each buffered record, since a buffer may be shared between
+// handlers with different attributes (from [slog.Handler.WithAttrs]), and we want the record to be
+// handled as if it was not buffered.
+type bufferedRecord struct {
+	ctx     context.Context
+	record  slog.Record
+	handler slog.Handler
+}
+
+func (buffer *logBuffer) handle(
+	ctx context.Context,
+	record slog.Record,
+	handler bufferHandler,
+) error {
+	buffer.lock.Lock()
+
+	switch buffer.state {
+	case bufferStateBuffering:
+		// Handled below
+	case bufferStateFlushed:
+		buffer.lock.Unlock()
+		if record.Level < handler.bufferLevel.Level() {
+			return nil
+		}
+		return handler.wrapped.Handle(ctx, record)
+	case bufferStateEnded:
+		buffer.lock.Unlock()
+		// The buffer handler's Enabled method may have returned true while the buffer was active,
+		// so we must check the wrapped handler's level here
+		if !handler.wrapped.Enabled(ctx, record.Level) {
+			return nil
+		}
+		return handler.wrapped.Handle(ctx, record)
+	}
+
+	defer buffer.lock.Unlock()
+
+	if record.Level < handler.bufferLevel.Level() {
+		return nil
+	}
+
+	if record.Level < handler.triggerLevel.Level() {
+		// We resolve attributes when the log is made, so that the log shows values as they were
+		// at that time, not when the buffer is flushed. This also copies the record, which we must
+		// do since it may be modified by the caller after we return.
+		buffered := bufferedRecord{ctx: ctx, record: resolveRecord(record), handler: handler.wrapped}
+
+		if len(buffer.records) < handler.maxRecords {
+			buffer.records = append(buffer.records, buffered)
+		} else {
+			// Overwrites the oldest record
+			buffer.records[buffer.start] = buffered
+			buffer.start = (buffer.start + 1) % len(buffer.records)
+		}
+		return nil
+	}
+
+	// We hold the lock while flushing, so that logs made concurrently in the same context are
+	// written after the flushed logs
+	var errs []error
+	for i := range buffer.records {
+		buffered := buffer.records[(buffer.start+i)%len(buffer.records)]
+		if err := buffered.handler.Handle(buffered.ctx, buffered.record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	buffer.records = nil
+	buffer.start = 0
+	buffer.state = bufferStateFlushed
+
+	if err := handler.wrapped.Handle(ctx, record); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (buffer *logBuffer) isActive() bool {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.state != bufferStateEnded
+}
+
+func (buffer *logBuffer) end() {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	buffer.records = nil
+	buffer.start = 0
+	buffer.state = bufferStateEnded
+}
+
+// Returns a copy of the given record with resolved attribute values (see [slog.Value.Resolve]),
+// including values nested in groups.
+func resolveRecord(record slog.Record) slog.Record {
+	resolved := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			resolved.AddAttrs(resolveAttr(attr))
+			return true
+		},
+	)
+	return resolved
+}
+
+func resolveAttr(attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindLogValuer {
+		// Some values must be kept as log valuers, so that log handlers can recognize them (see
+		// noCacheLogValuer). These can instead give us a snapshot of their current state.
+		if valuer, ok := attr.Value.LogValuer().(snapshotLogValuer); ok {
+			attr.Value = slog.AnyValue(valuer.snapshot())
+			return attr
+		}
+	}
+
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		group := attr.Value.Group()
+		resolvedGroup := make([]slog.Attr, len(group))
+		for i, groupAttr := range group {
+			resolvedGroup[i] = resolveAttr(groupAttr)
+		}
+		attr.Value = slog.GroupValue(resolvedGroup...)
+	}
+	return attr
+}
+
+// snapshotLogValuer is implemented by log attribute values that we can't resolve when buffering a
+// log, since log handlers need to recognize them before resolving (such as the values from
+// [log.Diff] and [log.Table]). Instead, they return a copy of themselves with their current state.
+type snapshotLogValuer interface {
+	slog.LogValuer
+	snapshot() slog.LogValuer
+}
+
+func getLogBuffer(ctx context.Context) *logBuffer {
+	// We want to avoid a possible nil pointer dereference on Context.Value below
+	if ctx == nil {
+		return nil
+	}
+
+	buffer, _ := ctx.Value(logBufferKey).(*logBuffer)
+	return buffer
+}
+
+const defaultMaxBufferedRecords = 1000
+
+// Use struct{} to avoid allocations, as recommended by [context.WithValue].
+type logBufferKeyType struct{}
+
+var logBufferKey = logBufferKeyType{}
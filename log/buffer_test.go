@@ -0,0 +1,207 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+func TestBufferDiscardedWithoutTrigger(t *testing.T) {
+	logger, output := setupBufferLogger(nil)
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	logger.Debug(ctx, "Debug log")
+	logger.Info(ctx, "Info log")
+	logger.Warn(ctx, "Warn log")
+	endBuffer()
+
+	if output.Len() != 0 {
+		t.Errorf("Expected buffered logs to be discarded, got: %s", output.String())
+	}
+}
+
+func TestBufferFlushedOnTrigger(t *testing.T) {
+	logger, output := setupBufferLogger(nil)
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	logger.Debug(ctx, "Debug log 1")
+	logger.With("handlerKey", "value").Info(ctx, "Info log")
+
+	if output.Len() != 0 {
+		t.Fatalf("Expected logs to be buffered before trigger, got: %s", output.String())
+	}
+
+	logger.ErrorMessage(ctx, "Error log")
+	// Logs after the trigger should be written directly, including DEBUG logs
+	logger.Debug(ctx, "Debug log 2")
+	endBuffer()
+	// After the buffer's scope has ended, the wrapped handler's level should apply again
+	logger.Debug(ctx, "Debug log 3")
+
+	assertLogMessages(t, output, "Debug log 1", "Info log", "Error log", "Debug log 2")
+	assertContains(t, output.String(), `"msg":"Info log","handlerKey":"value"`)
+}
+
+func TestLogWithoutBuffer(t *testing.T) {
+	logger, output := setupBufferLogger(nil)
+
+	logger.Debug(context.Background(), "Debug log")
+	logger.Info(context.Background(), "Info log")
+
+	assertLogMessages(t, output, "Info log")
+}
+
+func TestBufferOptions(t *testing.T) {
+	logger, output := setupBufferLogger(
+		&log.BufferOptions{
+			TriggerLevel: slog.LevelWarn,
+			BufferLevel:  slog.LevelInfo,
+			MaxRecords:   2,
+		},
+	)
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	defer endBuffer()
+
+	logger.Debug(ctx, "Below buffer level")
+	logger.Info(ctx, "Info log 1")
+	logger.Info(ctx, "Info log 2")
+	logger.Info(ctx, "Info log 3")
+	logger.Warn(ctx, "Warn log")
+
+	// First info log should be discarded, since MaxRecords is 2
+	assertLogMessages(t, output, "Info log 2", "Info log 3", "Warn log")
+}
+
+func TestBufferOverflowKeepsOrder(t *testing.T) {
+	logger, output := setupBufferLogger(&log.BufferOptions{MaxRecords: 3})
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	defer endBuffer()
+
+	for i := range 8 {
+		logger.Debug(ctx, "Debug log "+strconv.Itoa(i))
+	}
+	logger.ErrorMessage(ctx, "Error log")
+
+	assertLogMessages(t, output, "Debug log 5", "Debug log 6", "Debug log 7", "Error log")
+}
+
+func TestBufferResolvesValuesAtLogTime(t *testing.T) {
+	logger, output := setupBufferLogger(nil)
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	defer endBuffer()
+
+	counter := &counterLogValuer{count: 1}
+	user := map[string]any{"name": "Alice"}
+	logger.Debug(
+		ctx,
+		"Debug log",
+		"counter",
+		counter,
+		slog.Group("group", "counter", counter),
+		log.Diff("changes", map[string]any{}, user),
+		log.Table("users", []map[string]any{user}),
+	)
+
+	counter.count = 2
+	user["name"] = "Bob"
+	logger.ErrorMessage(ctx, "Error log")
+
+	assertLogMessages(t, output, "Debug log", "Error log")
+	assertContains(
+		t,
+		output.String(),
+		`"counter":1,"group":{"counter":1},"changes":{"name":{"after":"Alice"}},`+
+			`"users":[{"name":"Alice"}]`,
+	)
+}
+
+type counterLogValuer struct {
+	count int
+}
+
+func (counter *counterLogValuer) LogValue() slog.Value {
+	return slog.IntValue(counter.count)
+}
+
+func TestBufferWithContextHandler(t *testing.T) {
+	var output bytes.Buffer
+	// Use plain slog.Logger, since we want to test that BufferHandler works when we don't log
+	// through this library
+	logger := slog.New(
+		log.ContextHandler(
+			log.BufferHandler(
+				slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo}),
+				nil,
+			),
+		),
+	)
+
+	ctx, endBuffer := log.StartBuffer(context.Background())
+	defer endBuffer()
+	ctx = log.AddContextAttrs(ctx, "contextKey", "value")
+
+	logger.DebugContext(ctx, "Debug log")
+	logger.ErrorContext(ctx, "Error log")
+
+	assertLogMessages(t, &output, "Debug log", "Error log")
+	assertContains(t, output.String(), `"msg":"Debug log","contextKey":"value"`)
+}
+
+func TestNilBufferHandler(t *testing.T) {
+	var panicValue any
+
+	passNilToBufferHandler := func() {
+		defer func() {
+			panicValue = recover()
+		}()
+
+		log.BufferHandler(nil, nil)
+	}
+	passNilToBufferHandler()
+
+	expectedPanicValue := "nil slog.Handler given to BufferHandler"
+	if panicValue != expectedPanicValue {
+		t.Errorf(
+			`Unexpected panic value
+Want: %v
+ Got: %v`,
+			expectedPanicValue,
+			panicValue,
+		)
+	}
+}
+
+// Sets up a logger with BufferHandler, wrapping a JSON handler at the INFO level.
+func setupBufferLogger(options *log.BufferOptions) (log.Logger, *bytes.Buffer) {
+	var output bytes.Buffer
+	handler := slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo})
+	return log.New(log.BufferHandler(handler, options)), &output
+}
+
+func assertLogMessages(t *testing.T, output *bytes.Buffer, expectedMessages ...string) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != len(expectedMessages) {
+		t.Fatalf(
+			"Expected %d log lines, got %d:\n%s",
+			len(expectedMessages),
+			len(lines),
+			output.String(),
+		)
+	}
+
+	for i, expected := range expectedMessages {
+		if !strings.Contains(lines[i], `"msg":"`+expected+`"`) {
+			t.Errorf("Expected log line %d to have message '%s', got: %s", i, expected, lines[i])
+		}
+	}
+}
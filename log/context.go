@@ -3,6 +3,9 @@ package log
 import (
 	"context"
 	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
 )
 
 // AddContextAttrs returns a copy of the given parent context, with log attributes attached. When
@@ -33,6 +36,20 @@ import (
 // JSON object. This allows you to filter and query on the attributes in the log analysis tool of
 // your choice, in a more structured manner than if you were to just use string concatenation.
 //
+// # Lazy attributes
+//
+// Some context attributes may be expensive to compute, such as a serialized user object. Since
+// context attributes are often added well before we know whether a log will be made, you can pass
+// such values lazily with [log.Lazy]:
+//
+//	ctx = log.AddContextAttrs(ctx, "user", log.Lazy(func() any { return user.Summary() }))
+//
+// The function is then only called when a log at an enabled level includes the attribute (and not
+// if a log attribute with the same key overwrites it). The result is cached, so the function is
+// called at most once, even if the context is used for many logs. The same goes for values that
+// implement [slog.LogValuer]: their LogValue method is called once, the first time the attribute is
+// logged.
+//
 // # Attaching context attributes to errors
 //
 // Typically, when an error occurs, it is returned up the stack before being logged. This means that
@@ -68,14 +85,188 @@ func AddContextAttrs(parent context.Context, logAttributes ...any) context.Conte
 		parent = context.Background()
 	}
 
-	existingAttrs := getContextAttrs(parent)
+	attrs := parseAttrs(nil, logAttributes)
+	if len(attrs) == 0 {
+		return parent
+	}
+	cacheLogValuers(attrs)
+
+	return context.WithValue(parent, contextAttrsKey, newContextAttrs(parent, attrs, nil))
+}
+
+// AddContextGroup returns a copy of the given parent context, with the given log attributes
+// attached under a group with the given name. This lets you namespace context attributes, like
+// [Logger.WithGroup] does for logger attributes:
+//
+//	ctx = log.AddContextGroup(ctx, "job", "id", job.ID, "type", job.Type)
+//	log.Info(ctx, "Started job")
+//	// With slog.JSONHandler: {..., "msg": "Started job", "job": {"id": 1, "type": "EXPORT"}}
+//
+// If the parent context already has a group context attribute with the same name, then the new
+// attributes are merged into that group (overwriting previous attributes with the same keys in the
+// group). Otherwise, the group works like any other context attribute (see [log.AddContextAttrs]),
+// and overwrites a previous context attribute with the same key.
+//
+// If no attributes are given, the parent context is returned as-is, since empty groups are
+// omitted from log output.
+func AddContextGroup(
+	parent context.Context,
+	groupName string,
+	logAttributes ...any,
+) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	if len(logAttributes) == 0 {
+		return parent
+	}
+
+	groupAttrs := parseAttrs(nil, logAttributes)
+	cacheLogValuers(groupAttrs)
+	existingGroup, hasExistingGroup := getContextAttrsNode(parent).lookup(groupName)
+	if hasExistingGroup && existingGroup.Value.Kind() == slog.KindGroup {
+		groupAttrs = appendAttrs(groupAttrs, existingGroup.Value.Group())
+	}
+
+	attrs := []slog.Attr{{Key: groupName, Value: slog.GroupValue(groupAttrs...)}}
+	return context.WithValue(parent, contextAttrsKey, newContextAttrs(parent, attrs, nil))
+}
+
+// RemoveContextAttrs returns a copy of the given parent context, where context attributes with
+// the given keys (added by [log.AddContextAttrs] or [log.AddContextGroup] on the parent context or
+// any of its parents) are removed. Logs made with the returned context will not include these
+// attributes, while logs made with the parent context still do.
+//
+// To remove a group added by [log.AddContextGroup], pass the name of the group.
+//
+// If the parent context has none of the given keys, it's returned as-is.
+func RemoveContextAttrs(parent context.Context, keys ...string) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	node := getContextAttrsNode(parent)
+
+	var removedKeys []string
+	for _, key := range keys {
+		if _, exists := node.lookup(key); exists && !slices.Contains(removedKeys, key) {
+			removedKeys = append(removedKeys, key)
+		}
+	}
+
+	if len(removedKeys) == 0 {
+		return parent
+	}
+	return context.WithValue(parent, contextAttrsKey, newContextAttrs(parent, nil, removedKeys))
+}
+
+// ContextAttrs returns the log attributes that have been added to the given context with
+// [log.AddContextAttrs] (or any of its parents). The most recently added attributes come first.
+// Groups added by [log.AddContextGroup] are returned as group attributes, and attributes removed by
+// [log.RemoveContextAttrs] are not included.
+//
+// This is useful for middleware that wants to pass context attributes on to other systems, and
+// for asserting on context attributes in tests. The returned slice is a copy, so it's safe to
+// modify.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	return slices.Clone(getContextAttrs(ctx))
+}
+
+// Lazy returns a log attribute value that calls the given function the first time it's logged,
+// and caches the result for subsequent logs. This is useful for context attributes that are
+// expensive to compute (see [log.AddContextAttrs]), but it can be used for any log attribute:
+//
+//	ctx = log.AddContextAttrs(ctx, "user", log.Lazy(func() any { return user.Summary() }))
+//
+// The function is called when a log handler resolves the value, which only happens for logs at
+// enabled levels. It may be called from any goroutine that logs with the attribute, but at most
+// once. If the function returns a [slog.LogValuer], then that is resolved as well.
+//
+// Lazy panics if the given function is nil.
+func Lazy(valueFunc func() any) slog.LogValuer {
+	if valueFunc == nil {
+		panic("nil function given to log.Lazy")
+	}
+
+	return lazyValue(
+		sync.OnceValue(
+			func() slog.Value {
+				return slog.AnyValue(valueFunc()).Resolve()
+			},
+		),
+	)
+}
+
+// lazyValue implements [slog.LogValuer] for [log.Lazy]. The wrapped function is expected to cache
+// its result.
+type lazyValue func() slog.Value
+
+func (value lazyValue) LogValue() slog.Value {
+	return value()
+}
 
-	attrs := make([]slog.Attr, 0, len(existingAttrs)+len(logAttributes))
-	// Add new attrs first, so the most recent attrs show up first in the logs
-	attrs = parseAttrs(attrs, logAttributes)
-	attrs = appendAttrs(attrs, existingAttrs)
+// lazyValue caches its own result, so there's no need to wrap it in cacheLogValuers.
+func (lazyValue) noCache() {}
 
-	return context.WithValue(parent, contextAttrsKey, attrs)
+// noCacheLogValuer is implemented by log attribute values that cacheLogValuers should keep as-is:
+// values that already cache their result, and values that log handlers need to recognize before
+// resolving them (such as the values from [log.Diff] and [log.Table], which the devlog handler
+// shows in its own format).
+type noCacheLogValuer interface {
+	slog.LogValuer
+	noCache()
+}
+
+// Wraps attribute values that implement [slog.LogValuer] with [log.Lazy], so that they're only
+// resolved once, no matter how many logs use the context they're attached to. The given slice is
+// modified in place, so it must be owned by the caller.
+func cacheLogValuers(attrs []slog.Attr) {
+	for i, attr := range attrs {
+		if attr.Value.Kind() != slog.KindLogValuer {
+			continue
+		}
+
+		valuer := attr.Value.LogValuer()
+		if _, noCache := valuer.(noCacheLogValuer); noCache {
+			continue
+		}
+		attrs[i].Value = slog.AnyValue(Lazy(func() any { return valuer.LogValue() }))
+	}
+}
+
+// WithLevel returns a copy of the given parent context, with a log level that overrides the level
+// of the log handler for logs made in that context. This lets you enable debug logging for a single
+// request, without changing the global log level:
+//
+//	if req.Header.Get("X-Debug") == "true" {
+//		ctx = log.WithLevel(ctx, slog.LevelDebug)
+//	}
+//
+// The level is checked by the logging functions in this package (see [log.Enabled]), and by
+// [log.ContextHandler] for logs made through [log/slog] directly. In both cases, the wrapped
+// handler's Enabled method is bypassed: logs at or above the given level are passed to the handler,
+// and logs below it are dropped. Since handlers typically only check the level in Enabled, this
+// means that the given level also takes effect if it's higher than the handler's level.
+//
+// If WithLevel is called again on a child context, the new level overrides the previous one.
+func WithLevel(parent context.Context, level slog.Level) context.Context {
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	return context.WithValue(parent, contextLevelKey, level)
+}
+
+// Returns the level set on the context by [log.WithLevel], if any.
+func getContextLevel(ctx context.Context) (level slog.Level, ok bool) {
+	// We want to avoid a possible nil pointer dereference on Context.Value below
+	if ctx == nil {
+		return 0, false
+	}
+
+	level, ok = ctx.Value(contextLevelKey).(slog.Level)
+	return level, ok
 }
 
 // ContextHandler wraps a [slog.Handler], adding context attributes from [log.AddContextAttrs]
@@ -95,6 +286,9 @@ func AddContextAttrs(parent context.Context, logAttributes ...any) context.Conte
 //
 //	log.SetDefault(devlog.NewHandler(os.Stdout, nil))
 //
+// ContextHandler also checks log levels set on the context with [log.WithLevel], before falling
+// back to the wrapped handler's Enabled method.
+//
 // ContextHandler panics if the given handler is nil. If the handler is already wrapped by
 // ContextHandler, then it's returned as-is.
 func ContextHandler(wrapped slog.Handler) slog.Handler {
@@ -114,23 +308,44 @@ type contextHandler struct {
 
 func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
 	contextAttrs := getContextAttrs(ctx)
+	if len(contextAttrs) == 0 {
+		return handler.wrapped.Handle(ctx, record)
+	}
 
-ContextAttrLoop:
-	for _, contextAttr := range contextAttrs {
-		// Don't add the context attribute if the key already exists in the record's attributes
-		for existingAttr := range record.Attrs {
-			if existingAttr.Key == contextAttr.Key {
-				continue ContextAttrLoop
+	if record.NumAttrs() == 0 {
+		record.AddAttrs(contextAttrs...)
+		return handler.wrapped.Handle(ctx, record)
+	}
+
+	// Don't add context attributes if the key already exists in the record's attributes. We collect
+	// the record's keys once, instead of rescanning the record for every context attribute.
+	var recordKeys attrKeySet
+	for attr := range record.Attrs {
+		recordKeys.add(attr.Key)
+	}
+
+	for i, contextAttr := range contextAttrs {
+		if recordKeys.contains(contextAttr.Key) {
+			// Only allocate a new slice if we have to skip an attribute
+			filtered := slices.Clip(contextAttrs[:i])
+			for _, contextAttr := range contextAttrs[i+1:] {
+				if !recordKeys.contains(contextAttr.Key) {
+					filtered = append(filtered, contextAttr)
+				}
 			}
+			contextAttrs = filtered
+			break
 		}
-
-		record.AddAttrs(contextAttr)
 	}
 
+	record.AddAttrs(contextAttrs...)
 	return handler.wrapped.Handle(ctx, record)
 }
 
 func (handler contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if contextLevel, ok := getContextLevel(ctx); ok {
+		return level >= contextLevel
+	}
 	return handler.wrapped.Enabled(ctx, level)
 }
 
@@ -142,26 +357,118 @@ func (handler contextHandler) WithGroup(name string) slog.Handler {
 	return contextHandler{handler.wrapped.WithGroup(name)}
 }
 
+// Returns the deduplicated context attributes in the given context. The returned slice is shared
+// between all users of the context, so it must not be modified.
 func getContextAttrs(ctx context.Context) []slog.Attr {
+	return getContextAttrsNode(ctx).resolve()
+}
+
+func getContextAttrsNode(ctx context.Context) *contextAttrs {
 	// We want to avoid a possible nil pointer dereference on Context.Value below
 	if ctx == nil {
 		return nil
 	}
 
-	contextValue := ctx.Value(contextAttrsKey)
-	if contextValue == nil {
-		return nil
+	node, _ := ctx.Value(contextAttrsKey).(*contextAttrs)
+	return node
+}
+
+// contextAttrs is the value that we store in a context for context attributes. Every call to
+// AddContextAttrs (and AddContextGroup/RemoveContextAttrs) creates a new node that points to the
+// node of its parent context, so that we don't have to copy the parent's attributes on every call.
+// This keeps the cost of adding attributes constant, even in deep call chains.
+//
+// The full list of attributes (with newer attributes overwriting older ones with the same key) is
+// resolved the first time it's needed, which is typically when logging. It is then cached on the
+// node, so that subsequent logs with the same context (and resolution of child nodes) can reuse it.
+type contextAttrs struct {
+	parent *contextAttrs
+	// Attributes added by this node, most recent first.
+	attrs []slog.Attr
+	// Keys of attributes removed by this node (from RemoveContextAttrs).
+	removedKeys []string
+
+	// Cached result of resolve. We use an atomic pointer instead of sync.Once, so that we can check
+	// whether a parent has been resolved without resolving it. Two goroutines may resolve the same
+	// node concurrently, but they'll produce the same result, so that's fine.
+	resolved atomic.Pointer[[]slog.Attr]
+}
+
+func newContextAttrs(
+	parent context.Context,
+	attrs []slog.Attr,
+	removedKeys []string,
+) *contextAttrs {
+	//nolint:exhaustruct // Leaving resolved as zero value on purpose, as it's populated lazily
+	return &contextAttrs{
+		parent:      getContextAttrsNode(parent),
+		attrs:       attrs,
+		removedKeys: removedKeys,
 	}
+}
 
-	attrs, ok := contextValue.([]slog.Attr)
-	if !ok {
+func (node *contextAttrs) resolve() []slog.Attr {
+	if node == nil {
 		return nil
 	}
+	if cached := node.resolved.Load(); cached != nil {
+		return *cached
+	}
+
+	var attrs []slog.Attr
+	// Keys that we've either already added, or that have been removed by a newer node
+	var seenKeys attrKeySet
+
+	for current := node; current != nil; current = current.parent {
+		// If we reach a node that has already been resolved, we can use its cached attributes
+		// instead of traversing further
+		if current != node {
+			if cached := current.resolved.Load(); cached != nil {
+				for _, attr := range *cached {
+					if !seenKeys.contains(attr.Key) {
+						attrs = append(attrs, attr)
+					}
+				}
+				break
+			}
+		}
 
+		for _, key := range current.removedKeys {
+			seenKeys.add(key)
+		}
+		for _, attr := range current.attrs {
+			if !seenKeys.contains(attr.Key) {
+				seenKeys.add(attr.Key)
+				attrs = append(attrs, attr)
+			}
+		}
+	}
+
+	node.resolved.Store(&attrs)
 	return attrs
 }
 
+// Finds the most recent attribute with the given key, without resolving the full list of
+// attributes.
+func (node *contextAttrs) lookup(key string) (attr slog.Attr, found bool) {
+	for current := node; current != nil; current = current.parent {
+		if slices.Contains(current.removedKeys, key) {
+			return slog.Attr{}, false
+		}
+		for _, attr := range current.attrs {
+			if attr.Key == key {
+				return attr, true
+			}
+		}
+	}
+	return slog.Attr{}, false
+}
+
 // Use struct{} to avoid allocations, as recommended by [context.WithValue].
 type contextAttrsKeyType struct{}
 
 var contextAttrsKey = contextAttrsKeyType{}
+
+type contextLevelKeyType struct{}
+
+var contextLevelKey = contextLevelKeyType{}
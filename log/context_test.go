@@ -3,11 +3,16 @@ package log_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
+	"testing/slogtest"
 	"time"
 
 	"hermannm.dev/devlog/log"
@@ -101,6 +106,340 @@ func TestAddContextAttrsNilParent(t *testing.T) {
 	verifyLogAttrs(t, output, `"ctxKey":"value"`)
 }
 
+func TestContextAttrs(t *testing.T) {
+	ctx := log.AddContextAttrs(context.Background(), "ctxKey1", "value1", "ctxKey2", "value2")
+	ctx = log.AddContextAttrs(ctx, "ctxKey3", "value3", "ctxKey1", "overwritten")
+
+	attrs := log.ContextAttrs(ctx)
+	expected := []slog.Attr{
+		slog.String("ctxKey3", "value3"),
+		slog.String("ctxKey1", "overwritten"),
+		slog.String("ctxKey2", "value2"),
+	}
+	if !reflect.DeepEqual(attrs, expected) {
+		t.Errorf("Expected context attrs %v, got %v", expected, attrs)
+	}
+
+	// Modifying the returned slice should not affect the context
+	attrs[0] = slog.String("modified", "value")
+	if attrs := log.ContextAttrs(ctx); !reflect.DeepEqual(attrs, expected) {
+		t.Errorf("Expected context attrs to be unchanged after modifying copy, got %v", attrs)
+	}
+
+	if attrs := log.ContextAttrs(nil); len(attrs) != 0 {
+		t.Errorf("Expected no context attrs for nil context, got %v", attrs)
+	}
+}
+
+func TestAddContextGroup(t *testing.T) {
+	ctx := log.AddContextAttrs(context.Background(), "ctxKey", "value1")
+	ctx = log.AddContextGroup(ctx, "job", "id", 1, "type", "EXPORT")
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+	verifyLogAttrs(t, output, `"job":{"id":1,"type":"EXPORT"},"ctxKey":"value1"`)
+
+	// Adding to the same group again should merge with the existing group
+	ctx = log.AddContextGroup(ctx, "job", "type", "IMPORT", "step", 2)
+
+	output = getLogOutput(
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+	verifyLogAttrs(t, output, `"job":{"type":"IMPORT","step":2,"id":1},"ctxKey":"value1"`)
+}
+
+func TestAddContextGroupWithoutAttrs(t *testing.T) {
+	ctx := log.AddContextAttrs(context.Background(), "ctxKey", "value")
+
+	if groupCtx := log.AddContextGroup(ctx, "group"); groupCtx != ctx {
+		t.Error("Expected AddContextGroup without attrs to return parent context as-is")
+	}
+}
+
+func TestRemoveContextAttrs(t *testing.T) {
+	parent := log.AddContextAttrs(
+		context.Background(),
+		"ctxKey1", "value1",
+		"ctxKey2", "value2",
+		"ctxKey3", "value3",
+	)
+	parent = log.AddContextGroup(parent, "group", "key", "value")
+
+	ctx := log.RemoveContextAttrs(parent, "ctxKey1", "group", "nonExistentKey")
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+	verifyLogAttrs(t, output, `"ctxKey2":"value2","ctxKey3":"value3"`)
+
+	// Parent context should be unaffected
+	output = getLogOutput(
+		func() {
+			log.Info(parent, "Test")
+		},
+	)
+	verifyLogAttrs(
+		t,
+		output,
+		`"group":{"key":"value"},"ctxKey1":"value1","ctxKey2":"value2","ctxKey3":"value3"`,
+	)
+
+	// Removed attributes can be added again
+	ctx = log.AddContextAttrs(ctx, "ctxKey1", "newValue")
+	output = getLogOutput(
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+	verifyLogAttrs(t, output, `"ctxKey1":"newValue","ctxKey2":"value2","ctxKey3":"value3"`)
+}
+
+func TestRemoveNonExistentContextAttrs(t *testing.T) {
+	ctx := log.AddContextAttrs(context.Background(), "ctxKey", "value")
+
+	if removedCtx := log.RemoveContextAttrs(ctx, "otherKey"); removedCtx != ctx {
+		t.Error("Expected RemoveContextAttrs to return parent context as-is when no keys match")
+	}
+}
+
+func TestContextAttrsWithResolvedParent(t *testing.T) {
+	parent := log.AddContextAttrs(context.Background(), "ctxKey1", "value1", "ctxKey2", "value2")
+	// Resolves and caches the parent's context attributes
+	log.ContextAttrs(parent)
+
+	child := log.AddContextAttrs(parent, "ctxKey3", "value3", "ctxKey1", "overwritten")
+	child = log.RemoveContextAttrs(child, "ctxKey2")
+
+	assertContextAttrs(
+		t,
+		child,
+		slog.String("ctxKey3", "value3"),
+		slog.String("ctxKey1", "overwritten"),
+	)
+	assertContextAttrs(t, parent, slog.String("ctxKey1", "value1"), slog.String("ctxKey2", "value2"))
+}
+
+func TestManyContextAttrs(t *testing.T) {
+	// Enough attributes to use a map for deduplication instead of linear search
+	const attrCount = 100
+
+	ctx := context.Background()
+	for i := range attrCount {
+		ctx = log.AddContextAttrs(ctx, fmt.Sprintf("key%d", i%(attrCount/2)), i)
+	}
+
+	attrs := log.ContextAttrs(ctx)
+	if len(attrs) != attrCount/2 {
+		t.Fatalf("Expected %d context attrs, got %d: %v", attrCount/2, len(attrs), attrs)
+	}
+	for i, attr := range attrs {
+		// Most recent attributes should come first, and overwrite older ones
+		expectedValue := int64(attrCount - 1 - i)
+		expectedKey := fmt.Sprintf("key%d", expectedValue%(attrCount/2))
+		if attr.Key != expectedKey || attr.Value.Int64() != expectedValue {
+			t.Errorf("Expected attr %s=%d at index %d, got %v", expectedKey, expectedValue, i, attr)
+		}
+	}
+
+	// Log attributes should take precedence over context attributes
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test", "key0", "logValue")
+		},
+	)
+	assertContains(t, output, `"key0":"logValue"`)
+	if strings.Count(output, `"key0"`) != 1 {
+		t.Errorf("Expected key0 to only appear once in log output, got: %s", output)
+	}
+}
+
+func TestLazyContextAttrs(t *testing.T) {
+	calls := 0
+	ctx := log.AddContextAttrs(
+		context.Background(),
+		"lazyKey",
+		log.Lazy(
+			func() any {
+				calls++
+				return "lazyValue"
+			},
+		),
+	)
+
+	// Should not be evaluated when the log level is disabled, or when overwritten by a log attr
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{Level: slog.LevelInfo},
+		func() {
+			log.Debug(ctx, "Disabled")
+			log.Info(ctx, "Overwritten", "lazyKey", "logValue")
+		},
+	)
+	assertContains(t, output, `"lazyKey":"logValue"`)
+	if calls != 0 {
+		t.Errorf("Expected lazy value to not be evaluated yet, but was evaluated %d times", calls)
+	}
+
+	output = getLogOutput(
+		func() {
+			log.Info(ctx, "Test 1")
+			log.Info(ctx, "Test 2")
+		},
+	)
+	if strings.Count(output, `"lazyKey":"lazyValue"`) != 2 {
+		t.Errorf("Expected lazy value in both logs, got: %s", output)
+	}
+	if calls != 1 {
+		t.Errorf("Expected lazy value to be evaluated once, but was evaluated %d times", calls)
+	}
+}
+
+func TestLazyErrorContextAttrs(t *testing.T) {
+	calls := 0
+	err := errorWithCtx{
+		log.AddContextAttrs(
+			context.Background(),
+			"lazyKey", log.Lazy(
+				func() any {
+					calls++
+					return "lazyValue"
+				},
+			),
+		),
+	}
+
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{Level: slog.LevelError},
+		func() {
+			log.WarnError(context.Background(), err, "Disabled")
+			log.Error(context.Background(), err, "Test 1")
+			log.Error(context.Background(), err, "Test 2")
+		},
+	)
+	if strings.Count(output, `"lazyKey":"lazyValue"`) != 2 {
+		t.Errorf("Expected lazy value in both error logs, got: %s", output)
+	}
+	if calls != 1 {
+		t.Errorf("Expected lazy value to be evaluated once, but was evaluated %d times", calls)
+	}
+}
+
+func TestLogValuerContextAttrsCached(t *testing.T) {
+	valuer := &countingLogValuer{calls: 0}
+	ctx := log.AddContextAttrs(context.Background(), "valuerKey", valuer)
+	ctx = log.AddContextGroup(ctx, "group", "groupValuerKey", valuer)
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test 1")
+			log.Info(ctx, "Test 2")
+		},
+	)
+	if strings.Count(output, `"valuerKey":"resolved"`) != 2 ||
+		strings.Count(output, `"group":{"groupValuerKey":"resolved"}`) != 2 {
+		t.Errorf("Expected resolved LogValuer in both logs, got: %s", output)
+	}
+	// Once for each of the 2 context attributes
+	if valuer.calls != 2 {
+		t.Errorf("Expected LogValue to be called 2 times, but was called %d times", valuer.calls)
+	}
+}
+
+type countingLogValuer struct {
+	calls int
+}
+
+func (valuer *countingLogValuer) LogValue() slog.Value {
+	valuer.calls++
+	return slog.StringValue("resolved")
+}
+
+func TestNilLazyFunc(t *testing.T) {
+	var panicValue any
+
+	passNilToLazy := func() {
+		defer func() {
+			panicValue = recover()
+		}()
+
+		log.Lazy(nil)
+	}
+	passNilToLazy()
+
+	expectedPanicValue := "nil function given to log.Lazy"
+	if panicValue != expectedPanicValue {
+		t.Errorf(
+			`Unexpected panic value
+Want: %v
+ Got: %v`,
+			expectedPanicValue,
+			panicValue,
+		)
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	ctx := log.WithLevel(context.Background(), slog.LevelDebug)
+
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{Level: slog.LevelInfo},
+		func() {
+			log.Debug(ctx, "Enabled by context")
+			log.Debug(context.Background(), "Disabled by handler")
+		},
+	)
+	assertContains(t, output, `"msg":"Enabled by context"`)
+	if strings.Contains(output, "Disabled by handler") {
+		t.Errorf("Expected DEBUG log without context level to be disabled, got: %s", output)
+	}
+
+	if !log.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Expected DEBUG level to be enabled for context with DEBUG level")
+	}
+}
+
+func TestWithHigherLevel(t *testing.T) {
+	ctx := log.WithLevel(context.Background(), slog.LevelDebug)
+	// Child context should override the parent's level
+	ctx = log.WithLevel(ctx, slog.LevelError)
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Disabled by context")
+			log.ErrorMessage(ctx, "Enabled")
+		},
+	)
+	assertContains(t, output, `"msg":"Enabled"`)
+	if strings.Contains(output, "Disabled by context") {
+		t.Errorf("Expected INFO log to be disabled by context level, got: %s", output)
+	}
+}
+
+func TestWithLevelInContextHandler(t *testing.T) {
+	var output bytes.Buffer
+	// Use plain slog.Logger, since we want to test that ContextHandler checks the context level
+	// when we don't log through this library
+	logger := slog.New(
+		log.ContextHandler(
+			slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		),
+	)
+
+	logger.DebugContext(log.WithLevel(context.Background(), slog.LevelDebug), "Enabled by context")
+	logger.DebugContext(context.Background(), "Disabled by handler")
+
+	assertContains(t, output.String(), `"msg":"Enabled by context"`)
+	if strings.Contains(output.String(), "Disabled by handler") {
+		t.Errorf("Expected DEBUG log without context level to be disabled, got: %s", output.String())
+	}
+}
+
 func TestContextHandler(t *testing.T) {
 	var output bytes.Buffer
 	// Use plain slog.Logger, since we want to test that ContextHandler works when we don't log
@@ -133,6 +472,29 @@ func TestContextHandler(t *testing.T) {
 	)
 }
 
+// Tests ContextHandler against the standard library test suite for structured log handlers, to
+// verify that it doesn't break the slog.Handler contract of the handler it wraps.
+//
+//nolint:thelper // We don't need to mark these as helper functions
+func TestContextHandlerConformance(t *testing.T) {
+	var output bytes.Buffer
+
+	slogtest.Run(
+		t,
+		func(t *testing.T) slog.Handler {
+			output.Reset()
+			return log.ContextHandler(slog.NewJSONHandler(&output, nil))
+		},
+		func(t *testing.T) map[string]any {
+			var entry map[string]any
+			if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+				t.Fatal(err)
+			}
+			return entry
+		},
+	)
+}
+
 func TestAlreadyWrappedContextHandler(t *testing.T) {
 	handler1 := log.ContextHandler(slog.NewJSONHandler(os.Stdout, nil))
 	handler2 := log.ContextHandler(handler1)
@@ -185,3 +547,78 @@ Want: %v
 		)
 	}
 }
+
+func assertContextAttrs(t *testing.T, ctx context.Context, expected ...slog.Attr) {
+	t.Helper()
+
+	if actual := log.ContextAttrs(ctx); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected context attributes\nWant: %v\n Got: %v", expected, actual)
+	}
+}
+
+// Measures the cost of adding an attribute to a context that already has a deep chain of context
+// attributes, which should stay roughly constant regardless of depth.
+func BenchmarkAddContextAttrs(b *testing.B) {
+	for _, depth := range []int{1, 10, 100, 1000} {
+		b.Run(
+			fmt.Sprintf("depth=%d", depth),
+			func(b *testing.B) {
+				ctx := contextWithDepth(depth)
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for range b.N {
+					log.AddContextAttrs(ctx, "newKey", "value")
+				}
+			},
+		)
+	}
+}
+
+// Measures the cost of logging with a deep chain of context attributes, where each log is made with
+// a newly added context attribute (i.e., the parent chain is cached, but the leaf is not).
+func BenchmarkLogWithContextAttrs(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(
+			fmt.Sprintf("depth=%d", depth),
+			func(b *testing.B) {
+				logger := log.New(slog.NewJSONHandler(io.Discard, nil))
+				ctx := contextWithDepth(depth)
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for i := range b.N {
+					logger.Info(log.AddContextAttrs(ctx, "iteration", i), "Test", "logKey", "value")
+				}
+			},
+		)
+	}
+}
+
+func BenchmarkContextHandler(b *testing.B) {
+	for _, depth := range []int{1, 10, 100} {
+		b.Run(
+			fmt.Sprintf("depth=%d", depth),
+			func(b *testing.B) {
+				logger := slog.New(log.ContextHandler(slog.NewJSONHandler(io.Discard, nil)))
+				ctx := contextWithDepth(depth)
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				for range b.N {
+					logger.InfoContext(ctx, "Test", "key0", "value", "logKey", "value")
+				}
+			},
+		)
+	}
+}
+
+// Returns a context where AddContextAttrs has been called the given number of times, each with a
+// unique key.
+func contextWithDepth(depth int) context.Context {
+	ctx := context.Background()
+	for i := range depth {
+		ctx = log.AddContextAttrs(ctx, fmt.Sprintf("key%d", i), i)
+	}
+	return ctx
+}
@@ -0,0 +1,194 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Diff returns a log attribute with the differences between the given before and after values,
+// for logging state changes without logging the full old and new states:
+//
+//	log.Info(ctx, "Updated user", log.Diff("changes", oldUser, newUser))
+//
+// The values are compared by their JSON representation, so struct fields are identified by their
+// JSON names, and unexported fields are ignored. Each changed field gets an entry with its path
+// (such as "address.city", or "tags[2]" for list elements), with the 'before' and 'after' values.
+// Added fields only have 'after', and removed fields only have 'before'. If the values themselves
+// are not objects or lists, the change has the path "$".
+//
+// When outputting logs as JSON, this gives a compact patch object:
+//
+//	{"changes":{"name":{"before":"Alice","after":"Bob"},"email":{"after":"bob@example.com"}}}
+//
+// The devlog handler shows the changes as a colored diff:
+//
+//	changes:
+//	  ~ name: "Alice" → "Bob"
+//	  + email: "bob@example.com"
+//
+// The diff is computed when the log is written, so it does not cost anything if the log level is
+// disabled. If a value can't be marshaled to JSON, the values are compared with
+// [reflect.DeepEqual], and logged in full if they differ.
+func Diff(key string, before any, after any) slog.Attr {
+	return slog.Any(key, diffValue{before: before, after: after})
+}
+
+type diffValue struct {
+	before any
+	after  any
+}
+
+// LogValue implements [slog.LogValuer], resolving the diff to a group of changes.
+func (diff diffValue) LogValue() slog.Value {
+	return slog.GroupValue(diff.DiffChanges()...)
+}
+
+// Kept as-is in context attributes, so that the devlog handler can recognize it.
+func (diffValue) noCache() {}
+
+// Used by BufferHandler to keep the values as they were when the log was made.
+func (diff diffValue) snapshot() slog.LogValuer {
+	return diffValue{before: snapshotJSON(diff.before), after: snapshotJSON(diff.after)}
+}
+
+// Returns the JSON representation of the given value as a [json.RawMessage], which marshals to the
+// same JSON as the original value. If the value can't be marshaled, it is returned as-is.
+func snapshotJSON(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	return json.RawMessage(data)
+}
+
+// DiffChanges returns the changes between the before and after values, as groups with the changed
+// path as key, and 'before' and 'after' attributes. The devlog handler checks for this method, to
+// show the diff in its own format.
+func (diff diffValue) DiffChanges() []slog.Attr {
+	before, beforeErr := toJSONValue(diff.before)
+	after, afterErr := toJSONValue(diff.after)
+	if beforeErr != nil || afterErr != nil {
+		if reflect.DeepEqual(diff.before, diff.after) {
+			return nil
+		}
+		return []slog.Attr{diffChange(diffRootPath, diff.before, true, diff.after, true)}
+	}
+
+	return appendDiffChanges(nil, "", before, after)
+}
+
+// Marshals and unmarshals the given value, to get its JSON representation as maps, slices and
+// primitives.
+func toJSONValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var jsonValue any
+	if err := decoder.Decode(&jsonValue); err != nil {
+		return nil, err
+	}
+	return jsonValue, nil
+}
+
+func appendDiffChanges(changes []slog.Attr, path string, before any, after any) []slog.Attr {
+	switch before := before.(type) {
+	case map[string]any:
+		if after, ok := after.(map[string]any); ok {
+			// Sorts keys, to get a stable order of changes (map order is random)
+			keys := make([]string, 0, len(before)+len(after))
+			for key := range before {
+				keys = append(keys, key)
+			}
+			for key := range after {
+				if _, inBefore := before[key]; !inBefore {
+					keys = append(keys, key)
+				}
+			}
+			slices.Sort(keys)
+
+			for _, key := range keys {
+				beforeField, inBefore := before[key]
+				afterField, inAfter := after[key]
+				fieldPath := joinDiffPath(path, key)
+
+				if inBefore && inAfter {
+					changes = appendDiffChanges(changes, fieldPath, beforeField, afterField)
+				} else {
+					changes = append(
+						changes,
+						diffChange(fieldPath, beforeField, inBefore, afterField, inAfter),
+					)
+				}
+			}
+			return changes
+		}
+	case []any:
+		if after, ok := after.([]any); ok {
+			for i := range max(len(before), len(after)) {
+				elementPath := path + "[" + strconv.Itoa(i) + "]"
+				inBefore := i < len(before)
+				inAfter := i < len(after)
+
+				if inBefore && inAfter {
+					changes = appendDiffChanges(changes, elementPath, before[i], after[i])
+				} else {
+					var beforeElement, afterElement any
+					if inBefore {
+						beforeElement = before[i]
+					}
+					if inAfter {
+						afterElement = after[i]
+					}
+					changes = append(
+						changes,
+						diffChange(elementPath, beforeElement, inBefore, afterElement, inAfter),
+					)
+				}
+			}
+			return changes
+		}
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return changes
+	}
+	if path == "" {
+		path = diffRootPath
+	}
+	return append(changes, diffChange(path, before, true, after, true))
+}
+
+func diffChange(path string, before any, hasBefore bool, after any, hasAfter bool) slog.Attr {
+	attrs := make([]slog.Attr, 0, 2)
+	if hasBefore {
+		attrs = append(attrs, slog.Any(diffBeforeKey, before))
+	}
+	if hasAfter {
+		attrs = append(attrs, slog.Any(diffAfterKey, after))
+	}
+	return slog.Attr{Key: path, Value: slog.GroupValue(attrs...)}
+}
+
+func joinDiffPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Path of the change when the diffed values are not objects or lists.
+const diffRootPath = "$"
+
+// Should be the same keys as in devlog's diff.go.
+const (
+	diffBeforeKey = "before"
+	diffAfterKey  = "after"
+)
@@ -0,0 +1,92 @@
+package log_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+type diffUser struct {
+	Name    string            `json:"name"`
+	Email   string            `json:"email,omitempty"`
+	Phone   string            `json:"phone,omitempty"`
+	Tags    []string          `json:"tags"`
+	Address diffAddress       `json:"address"`
+	Meta    map[string]string `json:"meta,omitempty"`
+}
+
+type diffAddress struct {
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+func TestDiff(t *testing.T) {
+	before := diffUser{
+		Name:    "Alice",
+		Email:   "",
+		Phone:   "12345678",
+		Tags:    []string{"admin", "beta"},
+		Address: diffAddress{City: "Oslo", Country: "Norway"},
+		Meta:    nil,
+	}
+	after := diffUser{
+		Name:    "Bob",
+		Email:   "bob@example.com",
+		Phone:   "",
+		Tags:    []string{"admin", "beta", "new"},
+		Address: diffAddress{City: "Bergen", Country: "Norway"},
+		Meta:    nil,
+	}
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Updated user", log.Diff("changes", before, after))
+		},
+	)
+
+	verifyLogAttrs(
+		t,
+		output,
+		`"changes":{"address.city":{"before":"Oslo","after":"Bergen"},`+
+			`"email":{"after":"bob@example.com"},`+
+			`"name":{"before":"Alice","after":"Bob"},`+
+			`"phone":{"before":"12345678"},`+
+			`"tags[2]":{"after":"new"}}`,
+	)
+}
+
+func TestDiffPrimitiveValues(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test", log.Diff("changed", 1, 2), log.Diff("unchanged", "a", "a"))
+		},
+	)
+
+	// Unchanged values should give an empty group, which slog omits
+	verifyLogAttrs(t, output, `"changed":{"$":{"before":1,"after":2}}`)
+}
+
+func TestDiffUnmarshalableValues(t *testing.T) {
+	before := func() {}
+	after := func() {}
+
+	attr := log.Diff("changes", before, after)
+	changes := attr.Value.Resolve().Group()
+	if len(changes) != 1 || changes[0].Key != "$" {
+		t.Errorf("Expected a single change at the root for unmarshalable values, got %v", changes)
+	}
+}
+
+func TestDiffInContextAttrs(t *testing.T) {
+	ctx := log.AddContextAttrs(ctx, log.Diff("changes", "a", "b"))
+
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{Level: slog.LevelInfo},
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+
+	verifyLogAttrs(t, output, `"changes":{"$":{"before":"a","after":"b"}}`)
+}
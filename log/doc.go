@@ -1,9 +1,28 @@
 // Package log is a thin wrapper over the [log/slog] package. It provides:
-//   - Utility functions for log message formatting ([log.Infof], [log.Errorf] etc.)
+//   - Utility functions for log message formatting ([log.Infof], [log.Errorf] etc.), and message
+//     templates that also add the formatted values as log attributes ([log.Infot], [log.Errort]
+//     etc.)
 //   - Error-aware logging functions, which structure errors to be formatted consistently as log
 //     attributes
 //   - [log.AddContextAttrs], a function for adding log attributes to a [context.Context], applying
-//     the attributes to all logs made in that context
+//     the attributes to all logs made in that context (along with [log.AddContextGroup] and
+//     [log.RemoveContextAttrs], for namespacing and removing context attributes,
+//     [log.ContextAttrs] for reading them, and [log.Lazy], for attribute values that are expensive
+//     to compute)
+//   - [log.WithLevel], for overriding the log level in a context (for example, to enable debug
+//     logging for a single request)
+//   - [log.BufferHandler], which buffers logs per context (see [log.StartBuffer]), and only writes
+//     them if a log at the ERROR level is made in the same context
+//   - [log.LimitHandler], which truncates large attribute values (long strings, deeply nested or
+//     large JSON values) before they reach your log handler
+//   - [log.Diff], an attribute for logging the changes between two values (shown as a colored diff
+//     by the devlog handler)
+//   - [log.Table], an attribute for logging a list of structs or maps (shown as an aligned table
+//     by the devlog handler)
+//   - Timing helpers ([log.Timer], [log.Observe]), which log the duration of an operation, and can
+//     log slow operations at a higher level
+//   - Panic recovery helpers ([log.RecoverPanic], [log.Go]), which log panics with their stack
+//     trace through your log handler
 //
 // # Attaching log attributes to errors
 //
@@ -25,6 +44,17 @@
 // we can still include attributes from the error's original context. The
 // [hermannm.dev/wrap/ctxwrap] package implements this.
 //
+// # Adding structured details to errors
+//
+// Errors can also contribute structured fields to their item in the 'cause' attribute, by
+// implementing [slog.LogValuer], if [ErrorOptions.IncludeErrorLogValues] is enabled:
+//
+//	LogValue() slog.Value
+//
+// If the returned value is a group, its attributes are shown as details under the error message.
+// For error types that you don't control (such as database driver errors), you can instead register
+// a formatter with [log.RegisterErrorFormatter].
+//
 // # Adding context attributes to logs made by log/slog
 //
 // When using [log.AddContextAttrs], context attributes are added to the log output when you use the
@@ -2,9 +2,12 @@ package log
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Same interface that the standard [errors] package uses to support error wrapping.
@@ -63,101 +66,322 @@ type hasContext interface {
 	Context() context.Context
 }
 
+// hasStringCode and hasIntCode are interfaces for errors that expose an error code. If
+// [ErrorOptions.IncludeErrorCodes] is enabled, we add the code to the error's item in the 'cause'
+// attribute.
+//
+// We don't export these interfaces, for the same reason as [hasWrappingMessage].
+type hasStringCode interface {
+	Code() string
+}
+
+type hasIntCode interface {
+	Code() int
+}
+
+// Same as [hasStringCode] and [hasIntCode], but for errors that use the ErrorCode() name, to avoid
+// conflict with a field named Code.
+type hasStringErrorCode interface {
+	ErrorCode() string
+}
+
+type hasIntErrorCode interface {
+	ErrorCode() int
+}
+
+// RegisterErrorFormatter registers a function to format errors of type E in the 'cause' attribute.
+// The returned log attributes are added as a "details" field to the error's item in the 'cause'
+// attribute, next to the error message. This lets domain errors contribute structured fields to
+// the log, such as the SQL state and constraint of a database error:
+//
+//	log.RegisterErrorFormatter(func(err *pgconn.PgError) []slog.Attr {
+//		return []slog.Attr{
+//			slog.String("sqlState", err.Code),
+//			slog.String("constraint", err.ConstraintName),
+//			slog.String("detail", err.Detail),
+//		}
+//	})
+//
+// The formatter is applied to each error in the cause chain whose type is E (if E is an interface
+// type, it's applied to errors that implement it). If multiple formatters match an error, the one
+// registered first is used. If [ErrorOptions.IncludeErrorLogValues] is enabled, errors that
+// implement [slog.LogValuer] contribute details in the same way (see the [log] package docs), but a
+// registered formatter takes precedence.
+//
+// RegisterErrorFormatter should typically be called once at the start of your program, along with
+// [log.SetDefault]. It returns a function that removes the formatter again, which is mostly useful
+// in tests. It panics if the given formatter is nil.
+func RegisterErrorFormatter[E error](formatter func(err E) []slog.Attr) (unregister func()) {
+	if formatter == nil {
+		panic("nil formatter given to log.RegisterErrorFormatter")
+	}
+
+	// We use a pointer to identify the formatter when unregistering it, since funcs can't be
+	// compared
+	registered := &errorFormatter{
+		format: func(err error) (details []slog.Attr, ok bool) {
+			//goland:noinspection GoTypeAssertionOnErrors - We only want to check the error itself
+			typedErr, ok := err.(E)
+			if !ok {
+				return nil, false
+			}
+			return formatter(typedErr), true
+		},
+	}
+
+	errorFormatters.lock.Lock()
+	defer errorFormatters.lock.Unlock()
+	errorFormatters.list = append(errorFormatters.list, registered)
+
+	return func() {
+		errorFormatters.lock.Lock()
+		defer errorFormatters.lock.Unlock()
+		errorFormatters.list = slices.DeleteFunc(
+			errorFormatters.list,
+			func(formatter *errorFormatter) bool { return formatter == registered },
+		)
+	}
+}
+
+type errorFormatter struct {
+	format func(err error) (details []slog.Attr, ok bool)
+}
+
+var errorFormatters struct {
+	lock sync.RWMutex
+	list []*errorFormatter
+}
+
+// Returns details from the first registered error formatter that matches the error, or from the
+// error's LogValue method if it implements [slog.LogValuer] (and
+// [ErrorOptions.IncludeErrorLogValues] is enabled).
+func getErrorDetails(err error, options ErrorOptions) []slog.Attr {
+	errorFormatters.lock.RLock()
+	for _, formatter := range errorFormatters.list {
+		if details, ok := formatter.format(err); ok {
+			errorFormatters.lock.RUnlock()
+			return details
+		}
+	}
+	errorFormatters.lock.RUnlock()
+
+	if !options.IncludeErrorLogValues {
+		return nil
+	}
+	if valuer, ok := err.(slog.LogValuer); ok {
+		value := valuer.LogValue().Resolve()
+		if value.Kind() == slog.KindGroup {
+			return value.Group()
+		}
+		return []slog.Attr{{Key: errorValueKey, Value: value}}
+	}
+
+	return nil
+}
+
+// ErrorOptions configure how the error-aware logging functions in this package format the 'cause'
+// attribute. Set them with [log.SetErrorOptions].
+type ErrorOptions struct {
+	// IncludeErrorTypes adds the Go type of each error in the cause chain (e.g. "*fs.PathError") to
+	// the error's item in the 'cause' attribute. Generic error types from the standard library that
+	// only carry a message (such as those created by [errors.New] and [fmt.Errorf]) are omitted.
+	IncludeErrorTypes bool
+
+	// IncludeErrorCodes adds the error code of each error in the cause chain to the error's item in
+	// the 'cause' attribute, for errors that implement one of the following methods:
+	//
+	//	Code() string
+	//	Code() int
+	//	ErrorCode() string
+	//	ErrorCode() int
+	IncludeErrorCodes bool
+
+	// IncludeErrorLogValues adds details to the error's item in the 'cause' attribute, for errors in
+	// the cause chain that implement [slog.LogValuer]. If the error's LogValue method returns a
+	// group, its attributes are used as details, otherwise the value is added under a "value" key.
+	// This is opt-in, so that errors that already implement LogValuer for other purposes don't
+	// change the log output. Formatters from [RegisterErrorFormatter] apply regardless.
+	IncludeErrorLogValues bool
+
+	// StructuredCause makes the 'cause' attribute a tree of objects, where each error is an object
+	// with a "message" field and a "causes" field for wrapped errors (along with "type" and "code",
+	// if enabled above). This gives a more structured output for JSON log handlers, instead of the
+	// default nested list of strings. The devlog handler displays both formats the same way.
+	//
+	// The attribute value implements [slog.LogValuer], resolving to a group of attributes.
+	StructuredCause bool
+
+	// MaxCauseDepth limits how many levels of wrapped errors are included in the 'cause' attribute.
+	// Errors beyond this depth are replaced by a "... N more" item, where N is the number of omitted
+	// errors. The outermost error in the 'cause' attribute is at depth 1.
+	//
+	// If 0 or negative, there is no limit.
+	MaxCauseDepth int
+}
+
+// SetErrorOptions configures how the error-aware logging functions in this package format the
+// 'cause' attribute. It applies to all loggers, and should typically be called once at the start of
+// your program, along with [log.SetDefault].
+//
+// When any of the options add extra information for an error, that error's item in the 'cause'
+// attribute becomes an object with "message", "type" and "code" fields, instead of a plain string.
+// The devlog handler displays these extra fields next to the error message.
+func SetErrorOptions(options ErrorOptions) {
+	errorOptions.Store(&options)
+}
+
+var errorOptions atomic.Pointer[ErrorOptions]
+
+func getErrorOptions() ErrorOptions {
+	if options := errorOptions.Load(); options != nil {
+		return *options
+	}
+	return ErrorOptions{}
+}
+
 func appendCauseError(attrs []slog.Attr, err error) []slog.Attr {
-	errorLog, attrs := buildErrorLog(err, attrs)
-	return prependCauseErrorAttr(errorLog, attrs)
+	cause, attrs := buildCauseError(err, attrs)
+	return prependCauseErrorAttr(causeErrorLogValue(cause), attrs)
 }
 
 func appendCauseErrors(attrs []slog.Attr, errors []error) []slog.Attr {
-	errorLog, attrs := buildErrorListLog(errors, attrs, false)
-	return prependCauseErrorAttr(errorLog, attrs)
+	causes, attrs := buildCauseErrorList(errors, attrs)
+	return prependCauseErrorAttr(causeErrorListLogValue(causes), attrs)
 }
 
-func buildErrorLog(err error, attrs []slog.Attr) (errorLog any, newAttrs []slog.Attr) {
-	attrs = appendErrorAttrs(attrs, err)
+// causeError is a node in the tree of errors that we build from an error chain, before we format it
+// as a 'cause' attribute. If [ErrorOptions.StructuredCause] is enabled, we use this tree directly
+// as the attribute value (through its LogValue method). Otherwise, we convert it to a nested list
+// of strings with [causeError.legacyLogValue].
+//
+// The struct fields are exported (with JSON tags) so that nested causes are encoded properly by
+// [slog.JSONHandler], which uses [encoding/json] for values that are not top-level attributes.
+type causeError struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Code    any    `json:"code,omitempty"`
+	// Same attributes as detailAttrs, but converted to a map so that they're encoded properly by
+	// encoding/json.
+	Details map[string]any `json:"details,omitempty"`
+	Causes  []*causeError  `json:"causes,omitempty"`
+
+	// Details from [RegisterErrorFormatter] or the error's LogValue method. We keep the original
+	// attributes to preserve their order in LogValue.
+	detailAttrs []slog.Attr
+
+	// Whether Causes come from an error that wraps multiple errors (Unwrap() []error) - we need
+	// this to convert the tree to the legacy format.
+	wrapsMultiple bool
+}
 
-	//goland:noinspection GoTypeAssertionOnErrors - We check wrapped errors ourselves
-	switch err := err.(type) {
-	case wrappedError:
-		unwrapped, errMessage, errMessageIsWrappingMessage := unwrapError(err)
-		if errMessageIsWrappingMessage {
-			errorLog, attrs = appendError(
-				initErrorLogValue(errMessage, 4),
-				attrs,
-				unwrapped,
-				false,
-			)
-		} else {
-			errorLog = errMessage
-			// Even if we couldn't unwrap a message, we still want to traverse the error chain for
-			// attrs from hasLogAttributes or hasContext
-			attrs = traverseErrorChainForAttrs(attrs, unwrapped)
+// LogValue implements [slog.LogValuer], so that the error tree becomes a group of attributes when
+// used as a log attribute value.
+func (cause *causeError) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 4)
+	attrs = append(attrs, slog.String(errorMessageKey, cause.Message))
+	if cause.Type != "" {
+		attrs = append(attrs, slog.String(errorTypeKey, cause.Type))
+	}
+	if cause.Code != nil {
+		attrs = append(attrs, slog.Any(errorCodeKey, cause.Code))
+	}
+	if len(cause.detailAttrs) != 0 {
+		attrs = append(
+			attrs,
+			slog.Attr{Key: errorDetailsKey, Value: slog.GroupValue(cause.detailAttrs...)},
+		)
+	}
+	if len(cause.Causes) != 0 {
+		attrs = append(attrs, slog.Any(errorCausesKey, causeErrorList(cause.Causes)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Returns the causes as []any, so that log handlers can iterate over them without knowing the
+// causeError type (the devlog handler checks each item for [slog.LogValuer]).
+func causeErrorList(causes []*causeError) []any {
+	list := make([]any, len(causes))
+	for i, cause := range causes {
+		list[i] = cause
+	}
+	return list
+}
+
+func newCauseError(err error, message string) *causeError {
+	//nolint:exhaustruct // We set the remaining fields below
+	cause := &causeError{Message: message}
+
+	options := getErrorOptions()
+	if options.IncludeErrorTypes {
+		cause.Type = getErrorType(err)
+	}
+	if options.IncludeErrorCodes {
+		cause.Code = getErrorCode(err)
+	}
+	if details := getErrorDetails(err, options); len(details) != 0 {
+		cause.detailAttrs = details
+		cause.Details = attrsToMap(details)
+	}
+
+	return cause
+}
+
+func attrsToMap(attrs []slog.Attr) map[string]any {
+	attrMap := make(map[string]any, len(attrs))
+	addAttrsToMap(attrMap, attrs)
+	return attrMap
+}
+
+func addAttrsToMap(attrMap map[string]any, attrs []slog.Attr) {
+	for _, attr := range attrs {
+		value := attr.Value.Resolve()
+		if value.Kind() != slog.KindGroup {
+			attrMap[attr.Key] = value.Any()
+			continue
 		}
-	case wrappedErrors:
-		unwrapped, errMessage, errMessageIsWrappingMessage := unwrapErrors(err)
-		if errMessageIsWrappingMessage {
-			errorListLogValue, newAttrs := buildErrorListLog(unwrapped, attrs, false)
-			attrs = newAttrs
 
-			if errorListLogValue != nil {
-				errorLog = []any{errMessage, errorListLogValue}
-			} else {
-				errorLog = errMessage
-			}
+		// Follows the slog convention of inlining groups with empty keys
+		if attr.Key == "" {
+			addAttrsToMap(attrMap, value.Group())
 		} else {
-			errorLog = errMessage
-			// Even if we couldn't unwrap a message, we still want to traverse the error chain for
-			// attrs from hasLogAttributes or hasContext
-			for _, err := range unwrapped {
-				attrs = traverseErrorChainForAttrs(attrs, err)
-			}
+			attrMap[attr.Key] = attrsToMap(value.Group())
 		}
-	default:
-		errorLog = err.Error()
 	}
-
-	attrs = appendErrorContextAttrs(attrs, err)
-	return errorLog, attrs
 }
 
-func appendError(
-	errorLog []any,
-	attrs []slog.Attr,
-	err error,
-	partOfList bool,
-) (newErrorLog []any, newAttrs []slog.Attr) {
+func buildCauseError(err error, attrs []slog.Attr) (cause *causeError, newAttrs []slog.Attr) {
 	attrs = appendErrorAttrs(attrs, err)
 
 	//goland:noinspection GoTypeAssertionOnErrors - We check wrapped errors ourselves
 	switch err := err.(type) {
 	case wrappedError:
 		unwrapped, errMessage, errMessageIsWrappingMessage := unwrapError(err)
+		cause = newCauseError(err, errMessage)
 		if errMessageIsWrappingMessage {
-			errorLog, attrs = appendWrappedError(
-				errorLog,
-				attrs,
-				errMessage,
-				unwrapped,
-				partOfList,
-			)
+			if joinedErrors, isJoined := getJoinedErrors(unwrapped); isJoined {
+				// Adds the joined errors directly, so we can remove duplicates between them
+				var joinedCauses []*causeError
+				attrs = appendErrorAttrs(attrs, unwrapped)
+				joinedCauses, attrs = buildCauseErrorList(joinedErrors, attrs)
+				attrs = appendErrorContextAttrs(attrs, unwrapped)
+				cause = wrapCauseErrors(cause, joinedCauses)
+			} else if unwrapped != nil {
+				var unwrappedCause *causeError
+				unwrappedCause, attrs = buildCauseError(unwrapped, attrs)
+				cause = wrapCauseError(cause, unwrappedCause)
+			}
 		} else {
-			errorLog = append(errorLog, errMessage)
 			// Even if we couldn't unwrap a message, we still want to traverse the error chain for
 			// attrs from hasLogAttributes or hasContext
 			attrs = traverseErrorChainForAttrs(attrs, unwrapped)
 		}
 	case wrappedErrors:
 		unwrapped, errMessage, errMessageIsWrappingMessage := unwrapErrors(err)
+		cause = newCauseError(err, errMessage)
 		if errMessageIsWrappingMessage {
-			errorLog, attrs = appendWrappedErrors(
-				errorLog,
-				attrs,
-				errMessage,
-				unwrapped,
-				partOfList,
-			)
+			cause.Causes, attrs = buildCauseErrorList(unwrapped, attrs)
+			cause.wrapsMultiple = true
 		} else {
-			errorLog = append(errorLog, errMessage)
 			// Even if we couldn't unwrap a message, we still want to traverse the error chain for
 			// attrs from hasLogAttributes or hasContext
 			for _, err := range unwrapped {
@@ -165,70 +389,338 @@ func appendError(
 			}
 		}
 	default:
-		errorLog = append(errorLog, err.Error())
+		cause = newCauseError(err, err.Error())
 	}
 
 	attrs = appendErrorContextAttrs(attrs, err)
-	return errorLog, attrs
+	return cause, attrs
 }
 
-func appendWrappedError(
-	errorLog []any,
+func buildCauseErrorList(
+	errors []error,
 	attrs []slog.Attr,
-	wrappingMessage string,
-	unwrappedErr error,
-	partOfList bool,
-) (newErrorLog []any, newAttrs []slog.Attr) {
-	if partOfList {
-		errorLog = appendToErrorLog(errorLog, wrappingMessage, 2)
-
-		var nestedErrorLog []any
-		nestedErrorLog, attrs = appendError(nestedErrorLog, attrs, unwrappedErr, partOfList)
-		errorLog = append(errorLog, nestedErrorLog)
-	} else {
-		errorLog = appendToErrorLog(errorLog, wrappingMessage, 4)
-		errorLog, attrs = appendError(errorLog, attrs, unwrappedErr, partOfList)
+) (causes []*causeError, newAttrs []slog.Attr) {
+	if len(errors) == 0 {
+		return nil, attrs
 	}
 
-	return errorLog, attrs
+	causes = make([]*causeError, 0, len(errors))
+	return appendCauseErrorList(causes, errors, attrs)
 }
 
-func appendWrappedErrors(
-	errorLog []any,
+func appendCauseErrorList(
+	causes []*causeError,
+	errors []error,
 	attrs []slog.Attr,
-	wrappingMessage string,
-	unwrappedErrs []error,
-	partOfList bool,
-) (newErrorLog []any, newAttrs []slog.Attr) {
-	errorLog = appendToErrorLog(errorLog, wrappingMessage, 2)
-	errorListLogValue, attrs := buildErrorListLog(unwrappedErrs, attrs, partOfList)
-	if errorListLogValue != nil {
-		errorLog = append(errorLog, errorListLogValue)
+) (newCauses []*causeError, newAttrs []slog.Attr) {
+	for _, err := range errors {
+		// Errors that just join other errors (like errors.Join) don't add anything to the list, so
+		// we add the joined errors to the list directly. This lets us remove duplicates between
+		// the joined errors and the rest of the list.
+		if joinedErrors, ok := getJoinedErrors(err); ok {
+			attrs = appendErrorAttrs(attrs, err)
+			causes, attrs = appendCauseErrorList(causes, joinedErrors, attrs)
+			attrs = appendErrorContextAttrs(attrs, err)
+			continue
+		}
+
+		var cause *causeError
+		cause, attrs = buildCauseError(err, attrs)
+		causes = appendUniqueCause(causes, cause)
 	}
 
-	return errorLog, attrs
+	return causes, attrs
 }
 
-// Returns nil if the given error list was empty.
-func buildErrorListLog(
-	errors []error,
-	attrs []slog.Attr,
-	partOfList bool,
-) (errorLog any, newAttrs []slog.Attr) {
-	switch len(errors) {
+// Appends the given cause to the list, unless it duplicates one of the causes already in the list.
+// A cause without wrapped errors is also considered a duplicate if its message is contained in
+// another cause's message, which happens when an error is wrapped with %v instead of %w (like
+// fmt.Errorf("dial failed: %v", err)). In the opposite case, where the new cause contains the
+// message of a cause already in the list, the new cause replaces it.
+func appendUniqueCause(causes []*causeError, cause *causeError) []*causeError {
+	if slices.ContainsFunc(causes, cause.equalMessages) {
+		return causes
+	}
+
+	if len(cause.Causes) == 0 && slices.ContainsFunc(causes, cause.isContainedIn) {
+		return causes
+	}
+
+	for i, existing := range causes {
+		if len(existing.Causes) == 0 && existing.isContainedIn(cause) {
+			causes[i] = cause
+			// The new cause may contain messages of other causes in the list as well
+			return slices.DeleteFunc(
+				causes,
+				func(other *causeError) bool {
+					return other != cause && len(other.Causes) == 0 && other.isContainedIn(cause)
+				},
+			)
+		}
+	}
+
+	return append(causes, cause)
+}
+
+// Sets the wrapped error as the cause of the wrapping error. If the wrapping error's message just
+// repeats the wrapped error's message, we collapse the two, so the same message is not shown twice.
+// This may happen with implementations of hasWrappingMessage that return the full error message.
+func wrapCauseError(wrapping *causeError, wrapped *causeError) *causeError {
+	if wrapping.Message == wrapped.Message {
+		if wrapped.Type == "" {
+			wrapped.Type = wrapping.Type
+		}
+		if wrapped.Code == nil {
+			wrapped.Code = wrapping.Code
+		}
+		if wrapped.detailAttrs == nil {
+			wrapped.detailAttrs = wrapping.detailAttrs
+			wrapped.Details = wrapping.Details
+		}
+		return wrapped
+	}
+
+	// If the wrapping message ends with the wrapped error's message, we cut it from the end (same
+	// as we do in unwrapError for errors wrapped with fmt.Errorf)
+	wrappingMessage, found := strings.CutSuffix(wrapping.Message, ": "+wrapped.Message)
+	if found && wrappingMessage != "" {
+		wrapping.Message = wrappingMessage
+	} else if len(wrapped.Causes) == 0 && wrapped.isContainedIn(wrapping) {
+		// If the wrapping message includes the wrapped error's message somewhere else, the wrapped
+		// error doesn't add anything, so we collapse it into the wrapping error
+		if wrapping.Type == "" {
+			wrapping.Type = wrapped.Type
+		}
+		if wrapping.Code == nil {
+			wrapping.Code = wrapped.Code
+		}
+		if wrapping.detailAttrs == nil {
+			wrapping.detailAttrs = wrapped.detailAttrs
+			wrapping.Details = wrapped.Details
+		}
+		return wrapping
+	}
+
+	wrapping.Causes = []*causeError{wrapped}
+	return wrapping
+}
+
+// Same as [wrapCauseError], for an error that wraps a list of errors (like an [errors.Join] wrapped
+// with fmt.Errorf). The list should already be deduplicated.
+func wrapCauseErrors(wrapping *causeError, wrapped []*causeError) *causeError {
+	switch len(wrapped) {
 	case 0:
-		return nil, attrs
+		return wrapping
+	case 1:
+		return wrapCauseError(wrapping, wrapped[0])
+	}
+
+	// Removes causes that just repeat part of the wrapping message
+	wrapped = slices.DeleteFunc(
+		wrapped,
+		func(cause *causeError) bool {
+			return len(cause.Causes) == 0 && cause.isContainedIn(wrapping)
+		},
+	)
+	if len(wrapped) == 1 {
+		return wrapCauseError(wrapping, wrapped[0])
+	}
+
+	wrapping.Causes = wrapped
+	wrapping.wrapsMultiple = len(wrapped) > 1
+	return wrapping
+}
+
+// Returns true if this error's message is part of the other error's message (but not equal to it).
+func (cause *causeError) isContainedIn(other *causeError) bool {
+	return cause.Message != "" &&
+		len(cause.Message) < len(other.Message) &&
+		strings.Contains(other.Message, cause.Message)
+}
+
+// Returns true if the errors have the same message, and the same messages in their causes.
+func (cause *causeError) equalMessages(other *causeError) bool {
+	return cause.Message == other.Message &&
+		slices.EqualFunc(cause.Causes, other.Causes, (*causeError).equalMessages)
+}
+
+// Checks if the given error wraps multiple errors without a wrapping message, and its error message
+// is just the messages of the wrapped errors joined by newlines (which is what [errors.Join] does).
+func getJoinedErrors(err error) (joinedErrors []error, ok bool) {
+	//goland:noinspection GoTypeAssertionOnErrors - We check wrapped errors ourselves
+	wrapper, ok := err.(wrappedErrors)
+	if !ok {
+		return nil, false
+	}
+	if _, hasWrappingMessage := wrapper.(hasWrappingMessage); hasWrappingMessage {
+		return nil, false
+	}
+
+	joinedErrors = wrapper.Unwrap()
+	if len(joinedErrors) == 0 {
+		return nil, false
+	}
+
+	var joinedMessage strings.Builder
+	for i, joinedErr := range joinedErrors {
+		if joinedErr == nil {
+			return nil, false
+		}
+		if i != 0 {
+			joinedMessage.WriteByte('\n')
+		}
+		joinedMessage.WriteString(joinedErr.Error())
+	}
+
+	if wrapper.Error() != joinedMessage.String() {
+		return nil, false
+	}
+	return joinedErrors, true
+}
+
+// Replaces causes beyond the given depth with a single "... N more" item, where N is the number of
+// omitted errors. The top-level error is at depth 1.
+func (cause *causeError) limitDepth(maxDepth int) {
+	if len(cause.Causes) == 0 {
+		return
+	}
+
+	if maxDepth <= 1 {
+		omittedCount := 0
+		for _, omitted := range cause.Causes {
+			omittedCount += omitted.count()
+		}
+
+		//nolint:exhaustruct // Marker for omitted errors only has a message
+		omittedMarker := &causeError{Message: fmt.Sprintf("... %d more", omittedCount)}
+		cause.Causes = []*causeError{omittedMarker}
+		cause.wrapsMultiple = false
+		return
+	}
+
+	for _, wrapped := range cause.Causes {
+		wrapped.limitDepth(maxDepth - 1)
+	}
+}
+
+// Returns the number of errors in the tree, including this one.
+func (cause *causeError) count() int {
+	count := 1
+	for _, wrapped := range cause.Causes {
+		count += wrapped.count()
+	}
+	return count
+}
+
+func causeErrorLogValue(cause *causeError) any {
+	options := getErrorOptions()
+	if options.MaxCauseDepth > 0 {
+		cause.limitDepth(options.MaxCauseDepth)
+	}
+
+	if options.StructuredCause {
+		return cause
+	}
+	return cause.legacyLogValue()
+}
+
+// Returns nil if the given list is empty.
+func causeErrorListLogValue(causes []*causeError) any {
+	options := getErrorOptions()
+	if options.MaxCauseDepth > 0 {
+		for _, cause := range causes {
+			cause.limitDepth(options.MaxCauseDepth)
+		}
+	}
+
+	if options.StructuredCause {
+		switch len(causes) {
+		case 0:
+			return nil
+		case 1:
+			return causes[0]
+		default:
+			return causeErrorList(causes)
+		}
+	}
+
+	return legacyCauseErrorListLogValue(causes, false)
+}
+
+// Converts the error tree to the format we use for the 'cause' attribute when
+// [ErrorOptions.StructuredCause] is not enabled: a single string if the error has no causes, or
+// otherwise a list where a chain of wrapped errors is flattened, and errors that wrap multiple
+// errors are represented by a nested list.
+func (cause *causeError) legacyLogValue() any {
+	if len(cause.Causes) == 0 {
+		return cause.legacyItem()
+	}
+
+	if cause.wrapsMultiple {
+		return []any{cause.legacyItem(), legacyCauseErrorListLogValue(cause.Causes, false)}
+	}
+
+	errorLog := initErrorLogValue(cause.legacyItem(), 4)
+	return appendLegacyCauseError(errorLog, cause.Causes[0], false)
+}
+
+func appendLegacyCauseError(errorLog []any, cause *causeError, partOfList bool) []any {
+	if len(cause.Causes) == 0 {
+		return append(errorLog, cause.legacyItem())
+	}
+
+	if cause.wrapsMultiple {
+		errorLog = appendToErrorLog(errorLog, cause.legacyItem(), 2)
+		return append(errorLog, legacyCauseErrorListLogValue(cause.Causes, partOfList))
+	}
+
+	if partOfList {
+		errorLog = appendToErrorLog(errorLog, cause.legacyItem(), 2)
+		return append(errorLog, appendLegacyCauseError(nil, cause.Causes[0], partOfList))
+	} else {
+		errorLog = appendToErrorLog(errorLog, cause.legacyItem(), 4)
+		return appendLegacyCauseError(errorLog, cause.Causes[0], partOfList)
+	}
+}
+
+// Returns nil if the given list is empty.
+func legacyCauseErrorListLogValue(causes []*causeError, partOfList bool) any {
+	switch len(causes) {
+	case 0:
+		return nil
 	case 1:
 		if !partOfList {
-			return buildErrorLog(errors[0], attrs)
+			return causes[0].legacyLogValue()
 		}
 	}
 
-	errorListLogValue := make([]any, 0, len(errors))
-	for _, err := range errors {
-		errorListLogValue, attrs = appendError(errorListLogValue, attrs, err, true)
+	errorLog := make([]any, 0, len(causes))
+	for _, cause := range causes {
+		errorLog = appendLegacyCauseError(errorLog, cause, true)
 	}
-	return errorListLogValue, attrs
+	return errorLog
+}
+
+// Returns the message of the error, unless we have more information about the error (from
+// [ErrorOptions] or error details), in which case we return a map with the message under
+// errorMessageKey.
+func (cause *causeError) legacyItem() any {
+	if cause.Type == "" && cause.Code == nil && cause.Details == nil {
+		return cause.Message
+	}
+
+	item := make(map[string]any, 4)
+	item[errorMessageKey] = cause.Message
+	if cause.Type != "" {
+		item[errorTypeKey] = cause.Type
+	}
+	if cause.Code != nil {
+		item[errorCodeKey] = cause.Code
+	}
+	if cause.Details != nil {
+		item[errorDetailsKey] = cause.Details
+	}
+	return item
 }
 
 // If errMessageIsWrappingMessage is true, then the returned errMessage is the wrapping message
@@ -317,6 +809,40 @@ func traverseErrorChainForAttrs(attrs []slog.Attr, err error) []slog.Attr {
 	return attrs
 }
 
+// Returns a blank string for the generic error types from the standard library, since they don't
+// tell the reader anything that the message doesn't already.
+func getErrorType(err error) string {
+	errorType := fmt.Sprintf("%T", err)
+	switch errorType {
+	case "*errors.errorString", "*errors.joinError", "*fmt.wrapError", "*fmt.wrapErrors":
+		return ""
+	default:
+		return errorType
+	}
+}
+
+// Returns nil if the error does not implement any of the error code interfaces, or if the code is
+// blank.
+func getErrorCode(err error) any {
+	//goland:noinspection GoTypeAssertionOnErrors - We only want to check the error itself here
+	switch err := err.(type) {
+	case hasStringCode:
+		if code := err.Code(); code != "" {
+			return code
+		}
+	case hasIntCode:
+		return err.Code()
+	case hasStringErrorCode:
+		if code := err.ErrorCode(); code != "" {
+			return code
+		}
+	case hasIntErrorCode:
+		return err.ErrorCode()
+	}
+
+	return nil
+}
+
 func initErrorLogValue(firstErrorItem any, capacity int) []any {
 	errorLog := make([]any, 0, capacity)
 	errorLog = append(errorLog, firstErrorItem)
@@ -346,14 +872,35 @@ func prependCauseErrorAttr(
 	}
 }
 
-// Should be the same key as in devlog/handler.go (we don't import this across packages, as that
+// Should be the same keys as in devlog/handler.go (we don't import this across packages, as that
 // would require a dependency between them, whereas they're currently independent from each other).
-const causeErrorAttrKey = "cause"
+const (
+	causeErrorAttrKey = "cause"
+	errorMessageKey   = "message"
+	errorTypeKey      = "type"
+	errorCodeKey      = "code"
+	errorDetailsKey   = "details"
+	errorCausesKey    = "causes"
+)
+
+// Key for the value from an error's LogValue method, if it does not resolve to a group.
+const errorValueKey = "value"
 
 func getErrorMessageAndCause(
 	err error,
 	attrs []slog.Attr,
 ) (message string, newAttrs []slog.Attr) {
+	// The error's message becomes the log message, so there's no item for it in the 'cause'
+	// attribute. We add its details as a separate attribute instead, so that they're not lost.
+	// The 'cause' attribute is prepended below, so the details end up right after it.
+	if details := getErrorDetails(err, getErrorOptions()); len(details) != 0 {
+		attrs = slices.Insert(
+			attrs,
+			0,
+			slog.Attr{Key: errorDetailsKey, Value: slog.GroupValue(details...)},
+		)
+	}
+
 	attrs = appendErrorAttrs(attrs, err)
 
 	//goland:noinspection GoTypeAssertionOnErrors - We check wrapped errors ourselves
@@ -361,7 +908,12 @@ func getErrorMessageAndCause(
 	case wrappedError:
 		unwrapped, errMessage, errMessageIsWrappingMessage := unwrapError(err)
 		message = errMessage
-		if errMessageIsWrappingMessage {
+		if joinedErrors, isJoined := getJoinedErrors(unwrapped); isJoined &&
+			errMessageIsWrappingMessage {
+			attrs = appendErrorAttrs(attrs, unwrapped)
+			attrs = appendCauseErrors(attrs, joinedErrors)
+			attrs = appendErrorContextAttrs(attrs, unwrapped)
+		} else if errMessageIsWrappingMessage {
 			attrs = appendCauseError(attrs, unwrapped)
 		} else {
 			// If we couldn't unwrap a wrapping message, we still want to traverse the error chain
@@ -436,6 +436,351 @@ func TestDuplicateErrorAndContextAttrKeys(t *testing.T) {
 	)
 }
 
+func TestErrorTypesAndCodes(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{IncludeErrorTypes: true, IncludeErrorCodes: true})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	err := fmt.Errorf(
+		"request failed: %w",
+		wrappedErrorWithMsg{
+			"query failed",
+			errorWithCode{msg: "duplicate key", code: "23505"},
+		},
+	)
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(
+		t,
+		output,
+		`"cause":["request failed",`+
+			`{"message":"query failed","type":"log_test.wrappedErrorWithMsg"},`+
+			`{"code":"23505","message":"duplicate key","type":"log_test.errorWithCode"}]`,
+	)
+}
+
+func TestErrorTypesAndCodesDisabled(t *testing.T) {
+	err := wrappedErrorWithMsg{"wrapping message", errorWithCode{msg: "error", code: "CODE"}}
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(t, output, `"cause":["wrapping message","error"]`)
+}
+
+func TestStructuredCause(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{StructuredCause: true})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	err := wrappedErrorWithMsg{
+		"wrapping message",
+		wrappedErrorsWithMsg{
+			"invalid user data",
+			[]error{
+				errors.New("invalid email"),
+				wrappedErrorWithMsg{"invalid username", errors.New("too long")},
+			},
+		},
+	}
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(
+		t,
+		output,
+		`"cause":{"message":"wrapping message","causes":[{"message":"invalid user data","causes":[`+
+			`{"message":"invalid email"},`+
+			`{"message":"invalid username","causes":[{"message":"too long"}]}]}]}`,
+	)
+}
+
+func TestStructuredCauseWithMultipleErrors(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{StructuredCause: true})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	output := getLogOutput(
+		func() {
+			log.Errors(ctx, []error{errors.New("error 1"), errors.New("error 2")}, "Test")
+		},
+	)
+
+	verifyLogAttrs(t, output, `"cause":[{"message":"error 1"},{"message":"error 2"}]`)
+}
+
+func TestErrorFormatter(t *testing.T) {
+	registerDatabaseErrorFormatter(t)
+
+	err := fmt.Errorf(
+		"failed to store user: %w",
+		&databaseError{msg: "duplicate key", sqlState: "23505", constraint: "users_email_key"},
+	)
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(
+		t,
+		output,
+		`"cause":["failed to store user",`+
+			`{"details":{"constraint":"users_email_key","sqlState":"23505"},"message":"duplicate key"}]`,
+	)
+}
+
+func TestErrorFormatterWithEmptyMessage(t *testing.T) {
+	registerDatabaseErrorFormatter(t)
+
+	output := getLogOutput(
+		func() {
+			log.Error(
+				ctx,
+				&databaseError{msg: "duplicate key", sqlState: "23505", constraint: "users_email_key"},
+				"",
+			)
+		},
+	)
+
+	// The error message becomes the log message, so its details should be added as an attribute
+	verifyLogOutput(
+		t,
+		output,
+		"ERROR",
+		"duplicate key",
+		`"details":{"sqlState":"23505","constraint":"users_email_key"}`,
+	)
+}
+
+func TestUnregisterErrorFormatter(t *testing.T) {
+	unregister := log.RegisterErrorFormatter(
+		func(err *databaseError) []slog.Attr {
+			return []slog.Attr{slog.String("sqlState", err.sqlState)}
+		},
+	)
+	unregister()
+
+	err := fmt.Errorf("failed to store user: %w", &databaseError{msg: "duplicate key"})
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(t, output, `"cause":["failed to store user","duplicate key"]`)
+}
+
+func TestErrorWithLogValue(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{StructuredCause: true, IncludeErrorLogValues: true})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	err := wrappedErrorWithMsg{
+		"wrapping message",
+		errorWithLogValue{slog.GroupValue(slog.Int("retries", 3), slog.String("host", "db"))},
+	}
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(
+		t,
+		output,
+		`"cause":{"message":"wrapping message","causes":[`+
+			`{"message":"test","details":{"host":"db","retries":3}}]}`,
+	)
+}
+
+func TestErrorWithLogValueNotIncludedByDefault(t *testing.T) {
+	err := wrappedErrorWithMsg{
+		"wrapping message",
+		errorWithLogValue{slog.GroupValue(slog.Int("retries", 3))},
+	}
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(t, output, `"cause":["wrapping message","test"]`)
+}
+
+func TestNilErrorFormatter(t *testing.T) {
+	var panicValue any
+
+	passNilToRegisterErrorFormatter := func() {
+		defer func() {
+			panicValue = recover()
+		}()
+
+		log.RegisterErrorFormatter[error](nil)
+	}
+	passNilToRegisterErrorFormatter()
+
+	expectedPanicValue := "nil formatter given to log.RegisterErrorFormatter"
+	if panicValue != expectedPanicValue {
+		t.Errorf(
+			`Unexpected panic value
+Want: %v
+ Got: %v`,
+			expectedPanicValue,
+			panicValue,
+		)
+	}
+}
+
+func TestDuplicateMessageInErrorChain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedCause string
+	}{
+		{
+			name:          "Wrapping message equal to wrapped message",
+			err:           wrappedErrorWithMsg{"the error", errors.New("the error")},
+			expectedCause: `"cause":"the error"`,
+		},
+		{
+			name: "Wrapping message ending with wrapped message",
+			err: wrappedErrorWithMsg{
+				"wrapping message: the error",
+				errors.New("the error"),
+			},
+			expectedCause: `"cause":["wrapping message","the error"]`,
+		},
+		{
+			name: "Duplicate errors in list",
+			err: wrappedErrorsWithMsg{
+				"wrapping message",
+				[]error{
+					errors.New("error 1"),
+					errors.New("error 2"),
+					errors.New("error 1"),
+					wrappedErrorWithMsg{"error 3", errors.New("error 4")},
+					wrappedErrorWithMsg{"error 3", errors.New("error 4")},
+				},
+			},
+			expectedCause: `"cause":["wrapping message",["error 1","error 2","error 3",["error 4"]]]`,
+		},
+		{
+			name: "Duplicates in nested errors.Join",
+			err: wrappedErrorsWithMsg{
+				"wrapping message",
+				[]error{
+					errors.Join(
+						errors.New("error 1"),
+						errors.Join(errors.New("error 2"), errors.New("error 1")),
+					),
+					errors.New("error 2"),
+					errors.New("error 3"),
+				},
+			},
+			expectedCause: `"cause":["wrapping message",["error 1","error 2","error 3"]]`,
+		},
+		{
+			name: "Error wrapped with %v",
+			err: fmt.Errorf(
+				"outer: %w",
+				fmt.Errorf("failed: %v", errors.New("connection refused")),
+			),
+			expectedCause: `"cause":["outer","failed: connection refused"]`,
+		},
+		{
+			name: "Error wrapped with both %v and %w",
+			err: fmt.Errorf(
+				"failed: %v: %w",
+				errors.New("connection refused"),
+				errors.New("connection refused"),
+			),
+			expectedCause: `"cause":["failed","connection refused"]`,
+		},
+		{
+			name: "Wrapping message containing wrapped message",
+			err: wrappedErrorWithMsg{
+				"request failed (connection refused), retrying",
+				errors.New("connection refused"),
+			},
+			expectedCause: `"cause":"request failed (connection refused), retrying"`,
+		},
+		{
+			name: "errors.Join with %v duplicate wrapped with %w",
+			err: fmt.Errorf(
+				"outer: %w",
+				errors.Join(
+					errors.New("connection refused"),
+					fmt.Errorf("dial failed: %v", errors.New("connection refused")),
+				),
+			),
+			expectedCause: `"cause":["outer","dial failed: connection refused"]`,
+		},
+		{
+			name: "Error in list contained in other error",
+			err: wrappedErrorsWithMsg{
+				"wrapping message",
+				[]error{
+					errors.New("timeout"),
+					fmt.Errorf("request failed: %v", errors.New("timeout")),
+					errors.New("other error"),
+				},
+			},
+			expectedCause: `"cause":["wrapping message",["request failed: timeout","other error"]]`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(
+			testCase.name, func(t *testing.T) {
+				output := getErrorLogOutput(testCase.err)
+				verifyLogAttrs(t, output, testCase.expectedCause)
+			},
+		)
+	}
+}
+
+func TestDuplicateMessageInJoinLoggedWithBlankMessage(t *testing.T) {
+	err := fmt.Errorf(
+		"outer: %w",
+		errors.Join(
+			errors.New("connection refused"),
+			fmt.Errorf("dial failed: %v", errors.New("connection refused")),
+		),
+	)
+
+	output := getLogOutput(
+		func() {
+			log.Error(ctx, err, "")
+		},
+	)
+
+	verifyLogOutput(t, output, "ERROR", "outer", `"cause":"dial failed: connection refused"`)
+}
+
+func TestMaxCauseDepth(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{MaxCauseDepth: 2})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	err := wrappedErrorWithMsg{
+		"error 1",
+		wrappedErrorsWithMsg{
+			"error 2",
+			[]error{
+				errors.New("error 3"),
+				wrappedErrorWithMsg{"error 4", errors.New("error 5")},
+			},
+		},
+	}
+
+	output := getErrorLogOutput(err)
+
+	verifyLogAttrs(t, output, `"cause":["error 1","error 2","... 3 more"]`)
+}
+
+func TestMaxCauseDepthWithMultipleErrors(t *testing.T) {
+	log.SetErrorOptions(log.ErrorOptions{MaxCauseDepth: 1})
+	t.Cleanup(func() { log.SetErrorOptions(log.ErrorOptions{}) })
+
+	output := getLogOutput(
+		func() {
+			log.Errors(
+				ctx,
+				[]error{
+					fmt.Errorf("error 1: %w", errors.New("error 2")),
+					errors.New("error 3"),
+				},
+				"Test",
+			)
+		},
+	)
+
+	verifyLogAttrs(t, output, `"cause":["error 1",["... 1 more"],"error 3"]`)
+}
+
 func getErrorLogOutput(err error) string {
 	return getLogOutput(
 		func() {
@@ -826,6 +1171,58 @@ func (err errorWithAttrsAndCtx) Context() context.Context {
 	return err.ctx
 }
 
+// Implements the hasStringCode interface.
+type errorWithCode struct {
+	msg  string
+	code string
+}
+
+func (err errorWithCode) Error() string {
+	return err.msg
+}
+
+func (err errorWithCode) Code() string {
+	return err.code
+}
+
+func registerDatabaseErrorFormatter(t *testing.T) {
+	t.Helper()
+
+	unregister := log.RegisterErrorFormatter(
+		func(err *databaseError) []slog.Attr {
+			return []slog.Attr{
+				slog.String("sqlState", err.sqlState),
+				slog.String("constraint", err.constraint),
+			}
+		},
+	)
+	t.Cleanup(unregister)
+}
+
+// Used to test log.RegisterErrorFormatter.
+type databaseError struct {
+	msg        string
+	sqlState   string
+	constraint string
+}
+
+func (err *databaseError) Error() string {
+	return err.msg
+}
+
+// Implements slog.LogValuer.
+type errorWithLogValue struct {
+	value slog.Value
+}
+
+func (err errorWithLogValue) Error() string {
+	return "test"
+}
+
+func (err errorWithLogValue) LogValue() slog.Value {
+	return err.value
+}
+
 // Verify that the errors we expect to implement the wrappedError interface actually do.
 //
 //nolint:exhaustruct
@@ -0,0 +1,22 @@
+module hermannm.dev/devlog/log/grpclog
+
+go 1.23.0
+
+require (
+	google.golang.org/grpc v1.75.1
+	hermannm.dev/devlog v0.7.0
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+	google.golang.org/protobuf v1.36.6 // indirect
+)
+
+// Uses the devlog module in this repository during development, so that changes to both modules can
+// be made together. Users of this module don't get this replace directive, so the devlog version
+// required above must be a released version with all the devlog APIs that this module uses. See
+// "Publishing a new release" in the README for the release order.
+replace hermannm.dev/devlog => ../..
@@ -0,0 +1,450 @@
+// Package grpclog provides [gRPC] interceptors that add call attributes to the context with
+// [log.AddContextAttrs], and log a line for every completed call.
+//
+// Example of how to set up a server with the interceptors:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(grpclog.UnaryServerInterceptor(nil)),
+//		grpc.ChainStreamInterceptor(grpclog.StreamServerInterceptor(nil)),
+//	)
+//
+// Any logs made with the [hermannm.dev/devlog/log] package (or with [log/slog], if the handler is
+// wrapped with [log.ContextHandler]) using the context of a gRPC handler will then include the
+// call's request ID, method and peer address.
+//
+// And for clients:
+//
+//	conn, err := grpc.NewClient(
+//		target,
+//		grpc.WithChainUnaryInterceptor(grpclog.UnaryClientInterceptor(nil)),
+//		grpc.WithChainStreamInterceptor(grpclog.StreamClientInterceptor(nil)),
+//	)
+//
+// The client interceptors pass the request ID of the caller's context on to the server (along with
+// the context attributes listed in [Options.PropagatedAttrs]), so that logs for the same request
+// can be correlated across services. The request ID is set on the context by the server
+// interceptors in this package, and by the [hermannm.dev/devlog/log/httplog] middleware.
+//
+// This package is a separate Go module, so that users of devlog who don't use gRPC don't get it
+// as a dependency. Add it to your project with:
+//
+//	go get hermannm.dev/devlog/log/grpclog
+//
+// This package is not to be confused with [google.golang.org/grpc/grpclog], which configures the
+// internal logging of the gRPC library itself.
+//
+// [gRPC]: https://grpc.io
+package grpclog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/baggage"
+	"hermannm.dev/devlog/log/requestid"
+)
+
+// Options configure the interceptors in this package.
+type Options struct {
+	// Handler is the log handler that call completion logs are sent to. If nil, the handler of
+	// [slog.Default] is used (looked up on every call, so that a later call to [slog.SetDefault] or
+	// [log.SetDefault] takes effect).
+	Handler slog.Handler
+
+	// RequestIDMetadataKey is the metadata key that request IDs are sent and received on.
+	// If blank, defaults to "x-request-id".
+	RequestIDMetadataKey string
+
+	// NewRequestID generates a request ID for incoming calls that don't have one.
+	// If nil, defaults to a random 128-bit hex string.
+	NewRequestID func() string
+
+	// LevelForCode determines the log level of the call completion log, based on the status code
+	// of the call.
+	//
+	// If nil, server interceptors default to ERROR for codes that indicate a server error
+	// (Unknown, DeadlineExceeded, Unimplemented, Internal, Unavailable and DataLoss), WARN for all
+	// other error codes, and INFO for OK. Client interceptors use the same defaults, except that
+	// they log successful calls at DEBUG, since the server typically logs them as well.
+	LevelForCode func(code codes.Code) slog.Level
+
+	// PropagatedAttrs lists keys of context attributes (from [log.AddContextAttrs]) that should be
+	// passed on to the server in the "baggage" metadata of outgoing calls by the client
+	// interceptors, using the W3C Baggage format (see [hermannm.dev/devlog/log/baggage]). On the
+	// server side, incoming attributes are only added to the context if their keys are in this
+	// list, so that clients can't add arbitrary attributes to the server's logs.
+	//
+	// Propagated attributes are received as string attributes, regardless of their original type.
+	// The request ID is always propagated, and does not need to be included here.
+	PropagatedAttrs []string
+}
+
+// UnaryServerInterceptor returns a server interceptor for unary calls, that:
+//   - Gets the request ID from the "x-request-id" metadata of the call (or generates a new one if
+//     missing), and sends it back in the response header
+//   - Adds the request ID, the full gRPC method name and the peer address as context attributes
+//     on the call context, using [log.AddContextAttrs] (along with any propagated attributes from
+//     the client, see [Options.PropagatedAttrs])
+//   - Logs a "Handled gRPC call" line when the handler returns, with the status code and the
+//     duration of the call (and the error, if any)
+//
+// If options is nil, the default options are used.
+func UnaryServerInterceptor(options *Options) grpc.UnaryServerInterceptor {
+	interceptor := newInterceptor(options)
+
+	return func(
+		ctx context.Context,
+		request any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		start := time.Now()
+
+		ctx, requestID := interceptor.serverContext(ctx, info.FullMethod)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(interceptor.options.RequestIDMetadataKey, requestID))
+
+		response, err := handler(ctx, request)
+		interceptor.logServerCall(ctx, start, err)
+		return response, err
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor for streaming calls. It does the same as
+// [UnaryServerInterceptor], but logs the completion line when the stream handler returns.
+//
+// If options is nil, the default options are used.
+func StreamServerInterceptor(options *Options) grpc.StreamServerInterceptor {
+	interceptor := newInterceptor(options)
+
+	return func(
+		server any,
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+
+		ctx, requestID := interceptor.serverContext(stream.Context(), info.FullMethod)
+		_ = stream.SetHeader(metadata.Pairs(interceptor.options.RequestIDMetadataKey, requestID))
+
+		err := handler(server, &serverStream{ServerStream: stream, ctx: ctx})
+		interceptor.logServerCall(ctx, start, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a client interceptor for unary calls, that:
+//   - Sends the request ID of the caller's context (if any, see the package docs) in the
+//     "x-request-id" metadata of the call, along with any context attributes listed in
+//     [Options.PropagatedAttrs]
+//   - Logs a "Completed gRPC call" line when the call returns, with the full gRPC method name, the
+//     status code and the duration of the call (and the error, if any)
+//
+// If options is nil, the default options are used.
+func UnaryClientInterceptor(options *Options) grpc.UnaryClientInterceptor {
+	interceptor := newInterceptor(options)
+
+	return func(
+		ctx context.Context,
+		method string,
+		request any,
+		reply any,
+		conn *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		callOptions ...grpc.CallOption,
+	) error {
+		start := time.Now()
+
+		err := invoker(interceptor.outgoingContext(ctx), method, request, reply, conn, callOptions...)
+		interceptor.logClientCall(ctx, method, start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a client interceptor for streaming calls. It does the same as
+// [UnaryClientInterceptor], but logs the completion line when the stream ends (i.e., when
+// receiving from the stream returns an error or [io.EOF], or closing the send direction of the
+// stream fails). Streams that the caller abandons without receiving until the end are logged when
+// the call context is canceled (which gRPC requires in order to release the stream's resources).
+//
+// If options is nil, the default options are used.
+func StreamClientInterceptor(options *Options) grpc.StreamClientInterceptor {
+	interceptor := newInterceptor(options)
+
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		conn *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		callOptions ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		start := time.Now()
+
+		stream, err := streamer(interceptor.outgoingContext(ctx), desc, conn, method, callOptions...)
+		if err != nil {
+			interceptor.logClientCall(ctx, method, start, err)
+			return nil, err
+		}
+
+		wrappedStream := &clientStream{
+			ClientStream:   stream,
+			serverStreams:  desc.ServerStreams,
+			logOnce:        sync.Once{},
+			stopContextLog: nil,
+			log: func(err error) {
+				interceptor.logClientCall(ctx, method, start, err)
+			},
+		}
+		// Logs the call if the context is canceled before the stream ends, so that we also log
+		// streams that the caller abandons. We use the same 'once' as the other logs, so that the
+		// call is only logged once if the stream ends because of the canceled context.
+		wrappedStream.stopContextLog = context.AfterFunc(
+			ctx,
+			func() {
+				wrappedStream.logOnce.Do(
+					func() { wrappedStream.log(status.FromContextError(ctx.Err()).Err()) },
+				)
+			},
+		)
+		return wrappedStream, nil
+	}
+}
+
+type interceptor struct {
+	options        Options
+	baggageOptions *baggage.Options
+}
+
+func newInterceptor(options *Options) interceptor {
+	interceptor := interceptor{options: Options{}, baggageOptions: nil}
+	if options != nil {
+		interceptor.options = *options
+	}
+
+	if interceptor.options.RequestIDMetadataKey == "" {
+		interceptor.options.RequestIDMetadataKey = defaultRequestIDMetadataKey
+	}
+	if interceptor.options.NewRequestID == nil {
+		interceptor.options.NewRequestID = requestid.New
+	}
+	if len(interceptor.options.PropagatedAttrs) != 0 {
+		interceptor.baggageOptions = &baggage.Options{
+			AllowedKeys:    interceptor.options.PropagatedAttrs,
+			MaxValueLength: 0,
+			MaxSize:        0,
+		}
+	}
+
+	return interceptor
+}
+
+func (interceptor interceptor) serverContext(
+	ctx context.Context,
+	method string,
+) (newCtx context.Context, requestID string) {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+
+	if values := incoming.Get(interceptor.options.RequestIDMetadataKey); len(values) != 0 {
+		requestID = values[0]
+	}
+	if !requestid.IsValid(requestID) {
+		requestID = interceptor.options.NewRequestID()
+	}
+
+	// Add propagated attributes first, so that the attributes we add below take precedence
+	if interceptor.baggageOptions != nil {
+		if values := incoming.Get(baggageMetadataKey); len(values) != 0 {
+			ctx = baggage.Decode(ctx, strings.Join(values, ","), interceptor.baggageOptions)
+		}
+	}
+
+	attrs := []any{slog.String(requestIDAttrKey, requestID), slog.String(methodAttrKey, method)}
+	if callPeer, ok := peer.FromContext(ctx); ok && callPeer.Addr != nil {
+		attrs = append(attrs, slog.String(peerAttrKey, callPeer.Addr.String()))
+	}
+	ctx = log.AddContextAttrs(ctx, attrs...)
+	ctx = requestid.NewContext(ctx, requestID)
+
+	return ctx, requestID
+}
+
+func (interceptor interceptor) logServerCall(ctx context.Context, start time.Time, err error) {
+	code := status.Code(err)
+
+	level := defaultServerLevelForCode(code)
+	if interceptor.options.LevelForCode != nil {
+		level = interceptor.options.LevelForCode(code)
+	}
+
+	interceptor.log(
+		ctx,
+		level,
+		err,
+		"Handled gRPC call",
+		slog.String(codeAttrKey, code.String()),
+		slog.Duration(durationAttrKey, time.Since(start)),
+	)
+}
+
+func (interceptor interceptor) logClientCall(
+	ctx context.Context,
+	method string,
+	start time.Time,
+	err error,
+) {
+	code := status.Code(err)
+
+	level := defaultClientLevelForCode(code)
+	if interceptor.options.LevelForCode != nil {
+		level = interceptor.options.LevelForCode(code)
+	}
+
+	interceptor.log(
+		ctx,
+		level,
+		err,
+		"Completed gRPC call",
+		slog.String(methodAttrKey, method),
+		slog.String(codeAttrKey, code.String()),
+		slog.Duration(durationAttrKey, time.Since(start)),
+	)
+}
+
+func (interceptor interceptor) log(
+	ctx context.Context,
+	level slog.Level,
+	err error,
+	message string,
+	logAttributes ...any,
+) {
+	logger := log.Default()
+	if interceptor.options.Handler != nil {
+		logger = log.New(interceptor.options.Handler)
+	}
+
+	if err != nil {
+		logger.LogWithError(ctx, level, err, message, logAttributes...)
+	} else {
+		logger.Log(ctx, level, message, logAttributes...)
+	}
+}
+
+// Adds the request ID and propagated attributes from the caller's context to the outgoing metadata.
+func (interceptor interceptor) outgoingContext(ctx context.Context) context.Context {
+	var pairs []string
+
+	if requestID := requestid.FromContext(ctx); requestID != "" {
+		pairs = append(pairs, interceptor.options.RequestIDMetadataKey, requestID)
+	}
+
+	if interceptor.baggageOptions != nil {
+		if encoded := baggage.Encode(ctx, interceptor.baggageOptions); encoded != "" {
+			pairs = append(pairs, baggageMetadataKey, encoded)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// serverStream wraps a [grpc.ServerStream] to return the context with our context attributes.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *serverStream) Context() context.Context {
+	return stream.ctx
+}
+
+// clientStream wraps a [grpc.ClientStream] to log when the stream ends.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	logOnce       sync.Once
+	log           func(err error)
+	// Stops the log on context cancellation, see StreamClientInterceptor.
+	stopContextLog func() bool
+}
+
+func (stream *clientStream) RecvMsg(message any) error {
+	err := stream.ClientStream.RecvMsg(message)
+
+	switch {
+	case err == io.EOF:
+		stream.end(nil)
+	case err != nil:
+		stream.end(err)
+	case !stream.serverStreams:
+		// If the server doesn't stream, then the call is complete after receiving one message
+		stream.end(nil)
+	}
+
+	return err
+}
+
+func (stream *clientStream) CloseSend() error {
+	err := stream.ClientStream.CloseSend()
+	// If closing fails, the stream is broken, and the caller is unlikely to receive from it
+	if err != nil {
+		stream.end(err)
+	}
+	return err
+}
+
+func (stream *clientStream) end(err error) {
+	stream.stopContextLog()
+	stream.logOnce.Do(func() { stream.log(err) })
+}
+
+func defaultServerLevelForCode(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown,
+		codes.DeadlineExceeded,
+		codes.Unimplemented,
+		codes.Internal,
+		codes.Unavailable,
+		codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+func defaultClientLevelForCode(code codes.Code) slog.Level {
+	if code == codes.OK {
+		return slog.LevelDebug
+	}
+	return defaultServerLevelForCode(code)
+}
+
+const (
+	defaultRequestIDMetadataKey = "x-request-id"
+	baggageMetadataKey          = "baggage"
+)
+
+// Uses the same request ID key as hermannm.dev/devlog/log/httplog, so that request IDs from HTTP
+// middleware are passed on by the client interceptors.
+const (
+	requestIDAttrKey = "requestId"
+	methodAttrKey    = "grpcMethod"
+	peerAttrKey      = "peer"
+	codeAttrKey      = "grpcCode"
+	durationAttrKey  = "duration"
+)
@@ -0,0 +1,384 @@
+//nolint:exhaustruct // Protobuf messages and gRPC test servers have fields that we don't set
+package grpclog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/grpclog"
+	"hermannm.dev/devlog/log/requestid"
+)
+
+func TestUnaryInterceptors(t *testing.T) {
+	test := setupTest(t, nil)
+
+	ctx := requestContext("test-request-id")
+	var header metadata.MD
+	_, err := test.client.Check(
+		ctx,
+		&grpc_health_v1.HealthCheckRequest{Service: "test"},
+		grpc.Header(&header),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+
+	if requestID := header.Get("x-request-id"); len(requestID) != 1 ||
+		requestID[0] != "test-request-id" {
+		t.Errorf("Expected request ID 'test-request-id' in response header, got %v", requestID)
+	}
+
+	logs := test.stopAndGetLogs()
+	if len(logs) != 3 {
+		t.Fatalf("Expected 3 logs, got %d: %v", len(logs), logs)
+	}
+
+	handlerLog := findLog(t, logs, "Inside handler")
+	serverLog := findLog(t, logs, "Handled gRPC call")
+	for _, logLine := range []map[string]any{handlerLog, serverLog} {
+		assertField(t, logLine, "requestId", "test-request-id")
+		assertField(t, logLine, "grpcMethod", "/grpc.health.v1.Health/Check")
+		assertField(t, logLine, "peer", "bufconn")
+	}
+	assertField(t, serverLog, "level", "INFO")
+	assertField(t, serverLog, "grpcCode", "OK")
+	assertDuration(t, serverLog)
+
+	clientLog := findLog(t, logs, "Completed gRPC call")
+	assertField(t, clientLog, "level", "DEBUG")
+	assertField(t, clientLog, "requestId", "test-request-id")
+	assertField(t, clientLog, "grpcMethod", "/grpc.health.v1.Health/Check")
+	assertField(t, clientLog, "grpcCode", "OK")
+	assertDuration(t, clientLog)
+}
+
+func TestUnaryInterceptorsWithError(t *testing.T) {
+	test := setupTest(t, nil)
+
+	_, err := test.client.Check(
+		context.Background(),
+		&grpc_health_v1.HealthCheckRequest{Service: "failing"},
+	)
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal error from gRPC call, got: %v", err)
+	}
+
+	logs := test.stopAndGetLogs()
+
+	for _, message := range []string{"Handled gRPC call", "Completed gRPC call"} {
+		logLine := findLog(t, logs, message)
+		assertField(t, logLine, "level", "ERROR")
+		assertField(t, logLine, "grpcCode", "Internal")
+		assertField(
+			t,
+			logLine,
+			"cause",
+			"rpc error: code = Internal desc = health check failed",
+		)
+	}
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	test := setupTest(t, nil)
+
+	ctx := requestContext("test-request-id")
+	stream, err := test.client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Unexpected error from stream: %v", err)
+			}
+			break
+		}
+	}
+
+	logs := test.stopAndGetLogs()
+	if len(logs) != 3 {
+		t.Fatalf("Expected 3 logs, got %d: %v", len(logs), logs)
+	}
+
+	handlerLog := findLog(t, logs, "Inside handler")
+	serverLog := findLog(t, logs, "Handled gRPC call")
+	for _, logLine := range []map[string]any{handlerLog, serverLog} {
+		assertField(t, logLine, "requestId", "test-request-id")
+		assertField(t, logLine, "grpcMethod", "/grpc.health.v1.Health/Watch")
+		assertField(t, logLine, "peer", "bufconn")
+	}
+	assertField(t, serverLog, "grpcCode", "OK")
+
+	clientLog := findLog(t, logs, "Completed gRPC call")
+	assertField(t, clientLog, "grpcMethod", "/grpc.health.v1.Health/Watch")
+	assertField(t, clientLog, "grpcCode", "OK")
+}
+
+func TestGeneratedRequestID(t *testing.T) {
+	test := setupTest(
+		t,
+		&grpclog.Options{NewRequestID: func() string { return "generated-id" }},
+	)
+
+	_, err := test.client.Check(
+		context.Background(),
+		&grpc_health_v1.HealthCheckRequest{Service: "test"},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+
+	logs := test.stopAndGetLogs()
+	assertField(t, findLog(t, logs, "Inside handler"), "requestId", "generated-id")
+	assertField(t, findLog(t, logs, "Handled gRPC call"), "requestId", "generated-id")
+}
+
+func TestPropagatedAttrs(t *testing.T) {
+	test := setupTest(t, &grpclog.Options{PropagatedAttrs: []string{"userId", "tenant"}})
+
+	ctx := log.AddContextAttrs(
+		context.Background(),
+		"userId", 1234,
+		"tenant", "acme & co",
+		"notPropagated", "value",
+	)
+	_, err := test.client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+
+	logs := test.stopAndGetLogs()
+	handlerLog := findLog(t, logs, "Inside handler")
+	assertField(t, handlerLog, "userId", "1234")
+	assertField(t, handlerLog, "tenant", "acme & co")
+	if _, ok := handlerLog["notPropagated"]; ok {
+		t.Errorf("Expected 'notPropagated' attribute to not be propagated to server")
+	}
+}
+
+func TestPropagatedAttrsNotInAllowList(t *testing.T) {
+	test := setupTest(t, nil)
+
+	// Sends propagated attributes manually, since the client interceptor would not send them when
+	// they're not in the allow-list
+	ctx := metadata.AppendToOutgoingContext(
+		context.Background(),
+		"baggage",
+		"userId=1234",
+	)
+	_, err := test.client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+
+	logs := test.stopAndGetLogs()
+	if _, ok := findLog(t, logs, "Inside handler")["userId"]; ok {
+		t.Errorf("Expected server to ignore propagated attribute that is not in allow-list")
+	}
+}
+
+func TestAbandonedClientStream(t *testing.T) {
+	test := setupTest(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := test.client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "test"})
+	if err != nil {
+		t.Fatalf("Unexpected error from gRPC call: %v", err)
+	}
+	// Abandons the stream without receiving from it
+	cancel()
+
+	// The client log is made in a separate goroutine when the context is canceled
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(test.output.String(), "Completed gRPC call") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected abandoned client stream to be logged, got: %s", test.output.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	logs := test.stopAndGetLogs()
+	clientLog := findLog(t, logs, "Completed gRPC call")
+	assertField(t, clientLog, "grpcMethod", "/grpc.health.v1.Health/Watch")
+	assertField(t, clientLog, "grpcCode", "Canceled")
+}
+
+type interceptorTest struct {
+	client         grpc_health_v1.HealthClient
+	output         *lockedBuffer
+	stopAndGetLogs func() []map[string]any
+}
+
+// Sets up the request ID in the same way as the server interceptors and the httplog middleware.
+func requestContext(requestID string) context.Context {
+	ctx := log.AddContextAttrs(context.Background(), "requestId", requestID)
+	return requestid.NewContext(ctx, requestID)
+}
+
+func setupTest(t *testing.T, options *grpclog.Options) interceptorTest {
+	t.Helper()
+
+	var output lockedBuffer
+	handler := slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})
+	if options == nil {
+		options = &grpclog.Options{}
+	}
+	options.Handler = handler
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpclog.UnaryServerInterceptor(options)),
+		grpc.ChainStreamInterceptor(grpclog.StreamServerInterceptor(options)),
+	)
+	grpc_health_v1.RegisterHealthServer(server, testHealthServer{logger: log.New(handler)})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(
+			func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			},
+		),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(grpclog.UnaryClientInterceptor(options)),
+		grpc.WithChainStreamInterceptor(grpclog.StreamClientInterceptor(options)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create gRPC client: %v", err)
+	}
+
+	stopped := false
+	stop := func() {
+		if !stopped {
+			stopped = true
+			_ = conn.Close()
+			// GracefulStop waits for handlers (and thus our interceptors) to return, so that all
+			// logs have been written when we read them
+			server.GracefulStop()
+		}
+	}
+	t.Cleanup(stop)
+
+	return interceptorTest{
+		client: grpc_health_v1.NewHealthClient(conn),
+		output: &output,
+		stopAndGetLogs: func() []map[string]any {
+			stop()
+			return parseLogs(t, output.String())
+		},
+	}
+}
+
+type testHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	logger log.Logger
+}
+
+func (server testHealthServer) Check(
+	ctx context.Context,
+	request *grpc_health_v1.HealthCheckRequest,
+) (*grpc_health_v1.HealthCheckResponse, error) {
+	server.logger.Info(ctx, "Inside handler")
+
+	if request.GetService() == "failing" {
+		return nil, status.Error(codes.Internal, "health check failed")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (server testHealthServer) Watch(
+	_ *grpc_health_v1.HealthCheckRequest,
+	stream grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse],
+) error {
+	server.logger.Info(stream.Context(), "Inside handler")
+
+	return stream.Send(
+		&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING},
+	)
+}
+
+// Log output is written from both client and server goroutines, so we need to synchronize reads.
+type lockedBuffer struct {
+	buffer bytes.Buffer
+	lock   sync.Mutex
+}
+
+func (buffer *lockedBuffer) Write(bytes []byte) (int, error) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.buffer.Write(bytes)
+}
+
+func (buffer *lockedBuffer) String() string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+	return buffer.buffer.String()
+}
+
+func parseLogs(t *testing.T, output string) []map[string]any {
+	t.Helper()
+
+	var logs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var logLine map[string]any
+		if err := json.Unmarshal([]byte(line), &logLine); err != nil {
+			t.Fatalf("Failed to parse log line '%s': %v", line, err)
+		}
+		logs = append(logs, logLine)
+	}
+	return logs
+}
+
+func findLog(t *testing.T, logs []map[string]any, message string) map[string]any {
+	t.Helper()
+
+	for _, logLine := range logs {
+		if logLine["msg"] == message {
+			return logLine
+		}
+	}
+	t.Fatalf("Expected log with message '%s', got: %v", message, logs)
+	return nil
+}
+
+func assertField(t *testing.T, logLine map[string]any, key string, expected any) {
+	t.Helper()
+
+	if actual := logLine[key]; actual != expected {
+		t.Errorf("Expected log field '%s' to be '%v', got '%v'", key, expected, actual)
+	}
+}
+
+func assertDuration(t *testing.T, logLine map[string]any) {
+	t.Helper()
+
+	if _, ok := logLine["duration"].(float64); !ok {
+		t.Errorf("Expected numeric 'duration' field in log, got %v", logLine["duration"])
+	}
+}
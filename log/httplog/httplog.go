@@ -0,0 +1,261 @@
+// Package httplog provides a [net/http] middleware that adds request attributes to the request
+// context with [log.AddContextAttrs], and logs a line for every completed request.
+//
+// Example of how to set it up:
+//
+//	log.SetDefault(devlog.NewHandler(os.Stdout, nil))
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/users/{id}", getUser)
+//
+//	server := &http.Server{Addr: ":8000", Handler: httplog.Middleware(mux, nil)}
+//
+// Any logs made with the [hermannm.dev/devlog/log] package (or with [log/slog], if the handler is
+// wrapped with [log.ContextHandler]) using the request context will then include the request's ID,
+// method, path and remote address:
+//
+//	func getUser(res http.ResponseWriter, req *http.Request) {
+//		log.Info(req.Context(), "Fetching user") // Includes 'requestId', 'method', 'path' etc.
+//	}
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/baggage"
+	"hermannm.dev/devlog/log/requestid"
+)
+
+// Options configure the [Middleware].
+type Options struct {
+	// Handler is the log handler that request completion logs are sent to. If nil, the handler of
+	// [slog.Default] is used (looked up on every request, so that a later call to
+	// [slog.SetDefault] or [log.SetDefault] takes effect).
+	Handler slog.Handler
+
+	// RequestIDHeader is the header to read request IDs from. If the incoming request has a valid
+	// ID in this header, then that is used as the request ID. Otherwise, a new ID is generated. The
+	// request ID is also set on this header in the response.
+	// If blank, defaults to "X-Request-ID".
+	RequestIDHeader string
+
+	// NewRequestID generates a request ID for requests that don't have one.
+	// If nil, defaults to a random 128-bit hex string.
+	NewRequestID func() string
+
+	// LevelForStatus determines the log level of the request completion log, based on the response
+	// status code. If nil, defaults to ERROR for 5xx status codes, WARN for 4xx status codes, and
+	// INFO for all other status codes.
+	LevelForStatus func(statusCode int) slog.Level
+
+	// PropagatedAttrs lists keys of context attributes that the middleware should read from the
+	// "baggage" header of incoming requests, and add to the request context. Attributes with other
+	// keys are ignored, so that clients can't add arbitrary attributes to your logs. See
+	// [hermannm.dev/devlog/log/baggage] for how to send these attributes from the client side.
+	//
+	// Propagated attributes are received as string attributes, regardless of their original type.
+	PropagatedAttrs []string
+}
+
+// Middleware wraps the given HTTP handler, so that for every request, it:
+//   - Gets the request ID from the X-Request-ID header (or generates a new one if missing), and
+//     sets it on the response headers
+//   - Adds the request ID, method, path and remote address as context attributes on the request
+//     context, using [log.AddContextAttrs]
+//   - Logs a "Handled request" line when the wrapped handler returns, with the response status
+//     code, the number of bytes written to the response body, and the duration of the request (if
+//     the handler panics, the request is logged with status 500, and the panic continues)
+//
+// The log level of the completion log depends on the status code (see
+// [Options.LevelForStatus]).
+//
+// If options is nil, the default options are used.
+func Middleware(next http.Handler, options *Options) http.Handler {
+	middleware := middleware{next: next, options: Options{}, baggageOptions: nil}
+	if options != nil {
+		middleware.options = *options
+	}
+
+	if middleware.options.RequestIDHeader == "" {
+		middleware.options.RequestIDHeader = defaultRequestIDHeader
+	}
+	if middleware.options.NewRequestID == nil {
+		middleware.options.NewRequestID = requestid.New
+	}
+	if middleware.options.LevelForStatus == nil {
+		middleware.options.LevelForStatus = defaultLevelForStatus
+	}
+	if len(middleware.options.PropagatedAttrs) != 0 {
+		middleware.baggageOptions = &baggage.Options{
+			AllowedKeys:    middleware.options.PropagatedAttrs,
+			MaxValueLength: 0,
+			MaxSize:        0,
+		}
+	}
+
+	return middleware
+}
+
+// RequestID returns the ID of the current request, if the given context is a request context from
+// [Middleware] (or a child of it). Otherwise, it returns a blank string.
+func RequestID(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
+type middleware struct {
+	next           http.Handler
+	options        Options
+	baggageOptions *baggage.Options
+}
+
+func (middleware middleware) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	requestID := req.Header.Get(middleware.options.RequestIDHeader)
+	if !requestid.IsValid(requestID) {
+		requestID = middleware.options.NewRequestID()
+	}
+	res.Header().Set(middleware.options.RequestIDHeader, requestID)
+
+	ctx := requestid.NewContext(req.Context(), requestID)
+	// Add propagated attributes first, so that the attributes we add below take precedence
+	if middleware.baggageOptions != nil {
+		ctx = baggage.Extract(ctx, req.Header, middleware.baggageOptions)
+	}
+	ctx = log.AddContextAttrs(
+		ctx,
+		slog.String(requestIDAttrKey, requestID),
+		slog.String(methodAttrKey, req.Method),
+		slog.String(pathAttrKey, req.URL.Path),
+		slog.String(remoteAddrAttrKey, req.RemoteAddr),
+	)
+
+	writer := &responseWriter{ResponseWriter: res, statusCode: 0, bytesWritten: 0}
+	// Logs from a deferred function, so that we also log requests where the handler panicked
+	// (which are the requests we most need logs for)
+	defer middleware.logRequest(ctx, writer, start)
+	middleware.next.ServeHTTP(writer, req.WithContext(ctx))
+}
+
+// Must be called directly with defer, as it recovers a panic from the wrapped handler in order to
+// log the request with a 500 status code, before it panics again with the same value (so that
+// net/http can handle it as usual).
+func (middleware middleware) logRequest(
+	ctx context.Context,
+	writer *responseWriter,
+	start time.Time,
+) {
+	panicValue := recover()
+
+	statusCode := writer.statusCode
+	if panicValue != nil {
+		statusCode = http.StatusInternalServerError
+	} else if statusCode == 0 {
+		// If the handler never called WriteHeader or Write, then net/http responds with 200 OK
+		statusCode = http.StatusOK
+	}
+
+	logger := log.Default()
+	if middleware.options.Handler != nil {
+		logger = log.New(middleware.options.Handler)
+	}
+	logger.Log(
+		ctx,
+		middleware.options.LevelForStatus(statusCode),
+		"Handled request",
+		slog.Int(statusAttrKey, statusCode),
+		slog.Int64(bytesAttrKey, writer.bytesWritten),
+		slog.Duration(durationAttrKey, time.Since(start)),
+	)
+
+	if panicValue != nil {
+		panic(panicValue)
+	}
+}
+
+// responseWriter wraps an [http.ResponseWriter] to record the status code and number of bytes
+// written.
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
+}
+
+func (writer *responseWriter) WriteHeader(statusCode int) {
+	// Informational (1xx) responses may be followed by another status code, so we don't record them
+	if writer.statusCode == 0 && statusCode >= 200 {
+		writer.statusCode = statusCode
+	}
+	writer.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (writer *responseWriter) Write(bytes []byte) (int, error) {
+	if writer.statusCode == 0 {
+		writer.statusCode = http.StatusOK
+	}
+	n, err := writer.ResponseWriter.Write(bytes)
+	writer.bytesWritten += int64(n)
+	return n, err
+}
+
+// Flush implements [http.Flusher], since many handlers check for it with a type assertion.
+func (writer *responseWriter) Flush() {
+	if flusher, ok := writer.ResponseWriter.(http.Flusher); ok {
+		if writer.statusCode == 0 {
+			writer.statusCode = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack implements [http.Hijacker], since websocket libraries and other code that takes over the
+// connection check for it with a type assertion. It returns [http.ErrNotSupported] if the wrapped
+// response writer does not implement it.
+func (writer *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := writer.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, readWriter, err := hijacker.Hijack()
+	if err == nil && writer.statusCode == 0 {
+		// Hijacked connections are typically upgraded to another protocol (such as websockets),
+		// with the response written directly to the connection
+		writer.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, readWriter, err
+}
+
+// Unwrap allows [http.ResponseController] to access the underlying response writer.
+func (writer *responseWriter) Unwrap() http.ResponseWriter {
+	return writer.ResponseWriter
+}
+
+func defaultLevelForStatus(statusCode int) slog.Level {
+	switch {
+	case statusCode >= 500:
+		return slog.LevelError
+	case statusCode >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+const defaultRequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDAttrKey  = "requestId"
+	methodAttrKey     = "method"
+	pathAttrKey       = "path"
+	remoteAddrAttrKey = "remoteAddr"
+	statusAttrKey     = "status"
+	bytesAttrKey      = "bytes"
+	durationAttrKey   = "duration"
+)
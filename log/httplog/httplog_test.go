@@ -0,0 +1,352 @@
+package httplog_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/httplog"
+)
+
+func TestMiddleware(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	logHandler := slog.NewJSONHandler(&outputBuffer, nil)
+
+	var requestIDInHandler string
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(res http.ResponseWriter, req *http.Request) {
+				requestIDInHandler = httplog.RequestID(req.Context())
+				log.New(logHandler).Info(req.Context(), "Inside handler")
+				_, _ = res.Write([]byte("hello"))
+			},
+		),
+		&httplog.Options{Handler: logHandler},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1?verbose=true", nil)
+	req.Header.Set("X-Request-ID", "test-request-id")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	if requestIDInHandler != "test-request-id" {
+		t.Errorf("expected request ID 'test-request-id' in handler, got '%s'", requestIDInHandler)
+	}
+	if header := res.Header().Get("X-Request-ID"); header != "test-request-id" {
+		t.Errorf("expected request ID 'test-request-id' in response header, got '%s'", header)
+	}
+
+	logs := parseLogs(t, &outputBuffer)
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d:\n%s", len(logs), outputBuffer.String())
+	}
+
+	for _, logLine := range logs {
+		assertField(t, logLine, "requestId", "test-request-id")
+		assertField(t, logLine, "method", "GET")
+		assertField(t, logLine, "path", "/users/1")
+		assertField(t, logLine, "remoteAddr", req.RemoteAddr)
+	}
+
+	completionLog := logs[1]
+	assertField(t, completionLog, "msg", "Handled request")
+	assertField(t, completionLog, "level", "INFO")
+	assertField(t, completionLog, "status", float64(200))
+	assertField(t, completionLog, "bytes", float64(5))
+	if _, ok := completionLog["duration"].(float64); !ok {
+		t.Errorf("expected numeric 'duration' field in log, got %v", completionLog["duration"])
+	}
+}
+
+func TestGeneratedRequestID(t *testing.T) {
+	var outputBuffer bytes.Buffer
+
+	for _, headerValue := range []string{"", "invalid\nrequest-id", strings.Repeat("a", 129)} {
+		outputBuffer.Reset()
+
+		handler := httplog.Middleware(
+			http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+			&httplog.Options{
+				Handler:      slog.NewJSONHandler(&outputBuffer, nil),
+				NewRequestID: func() string { return "generated-id" },
+			},
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if headerValue != "" {
+			req.Header.Set("X-Request-ID", headerValue)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		if header := res.Header().Get("X-Request-ID"); header != "generated-id" {
+			t.Errorf("expected generated request ID in response header, got '%s'", header)
+		}
+
+		logs := parseLogs(t, &outputBuffer)
+		if len(logs) != 1 {
+			t.Fatalf("expected 1 log, got %d:\n%s", len(logs), outputBuffer.String())
+		}
+		assertField(t, logs[0], "requestId", "generated-id")
+	}
+}
+
+func TestDefaultRequestIDGenerator(t *testing.T) {
+	var requestIDs []string
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(_ http.ResponseWriter, req *http.Request) {
+				requestIDs = append(requestIDs, httplog.RequestID(req.Context()))
+			},
+		),
+		&httplog.Options{Handler: slog.NewJSONHandler(io.Discard, nil)},
+	)
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if len(requestIDs[0]) != 32 {
+		t.Errorf("expected 32-character hex request ID, got '%s'", requestIDs[0])
+	}
+	if requestIDs[0] == requestIDs[1] {
+		t.Errorf("expected unique request IDs, got '%s' twice", requestIDs[0])
+	}
+}
+
+func TestLevelForStatus(t *testing.T) {
+	testCases := []struct {
+		statusCode    int
+		expectedLevel string
+	}{
+		{http.StatusOK, "INFO"},
+		{http.StatusFound, "INFO"},
+		{http.StatusNotFound, "WARN"},
+		{http.StatusInternalServerError, "ERROR"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(http.StatusText(testCase.statusCode), func(t *testing.T) {
+			var outputBuffer bytes.Buffer
+
+			handler := httplog.Middleware(
+				http.HandlerFunc(
+					func(res http.ResponseWriter, _ *http.Request) {
+						res.WriteHeader(testCase.statusCode)
+					},
+				),
+				&httplog.Options{Handler: slog.NewJSONHandler(&outputBuffer, nil)},
+			)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			logs := parseLogs(t, &outputBuffer)
+			if len(logs) != 1 {
+				t.Fatalf("expected 1 log, got %d:\n%s", len(logs), outputBuffer.String())
+			}
+			assertField(t, logs[0], "level", testCase.expectedLevel)
+			assertField(t, logs[0], "status", float64(testCase.statusCode))
+		})
+	}
+}
+
+func TestCustomLevelForStatus(t *testing.T) {
+	var outputBuffer bytes.Buffer
+
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(res http.ResponseWriter, _ *http.Request) {
+				res.WriteHeader(http.StatusNotFound)
+			},
+		),
+		&httplog.Options{
+			Handler: slog.NewJSONHandler(&outputBuffer, &slog.HandlerOptions{Level: slog.LevelDebug}),
+			LevelForStatus: func(int) slog.Level {
+				return slog.LevelDebug
+			},
+		},
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	logs := parseLogs(t, &outputBuffer)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d:\n%s", len(logs), outputBuffer.String())
+	}
+	assertField(t, logs[0], "level", "DEBUG")
+}
+
+func TestPropagatedAttrs(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	logHandler := slog.NewJSONHandler(&outputBuffer, nil)
+
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(_ http.ResponseWriter, req *http.Request) {
+				log.New(logHandler).Info(req.Context(), "Inside handler")
+			},
+		),
+		&httplog.Options{Handler: logHandler, PropagatedAttrs: []string{"userId"}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Baggage", "userId=1234,notAllowed=value")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	logs := parseLogs(t, &outputBuffer)
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d:\n%s", len(logs), outputBuffer.String())
+	}
+	for _, logLine := range logs {
+		assertField(t, logLine, "userId", "1234")
+		if _, ok := logLine["notAllowed"]; ok {
+			t.Errorf("expected attribute not in PropagatedAttrs to be ignored")
+		}
+	}
+}
+
+func TestResponseWriterUnwrap(t *testing.T) {
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(res http.ResponseWriter, _ *http.Request) {
+				if err := http.NewResponseController(res).Flush(); err != nil {
+					t.Errorf("expected ResponseController to find underlying Flusher, got: %v", err)
+				}
+			},
+		),
+		&httplog.Options{Handler: slog.NewJSONHandler(io.Discard, nil)},
+	)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !res.Flushed {
+		t.Error("expected response to be flushed")
+	}
+}
+
+func TestPanicInHandler(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(http.ResponseWriter, *http.Request) {
+				panic("handler failed")
+			},
+		),
+		&httplog.Options{Handler: slog.NewJSONHandler(&outputBuffer, nil)},
+	)
+
+	var panicValue any
+	func() {
+		defer func() {
+			panicValue = recover()
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	if panicValue != "handler failed" {
+		t.Errorf("expected middleware to panic again with same value, got: %v", panicValue)
+	}
+
+	logs := parseLogs(t, &outputBuffer)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d:\n%s", len(logs), outputBuffer.String())
+	}
+	assertField(t, logs[0], "msg", "Handled request")
+	assertField(t, logs[0], "level", "ERROR")
+	assertField(t, logs[0], "status", float64(500))
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	var outputBuffer bytes.Buffer
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(res http.ResponseWriter, _ *http.Request) {
+				hijacker, ok := res.(http.Hijacker)
+				if !ok {
+					t.Fatal("expected response writer to implement http.Hijacker")
+				}
+				conn, _, err := hijacker.Hijack()
+				if err != nil {
+					t.Fatalf("expected hijack to succeed, got: %v", err)
+				}
+				_ = conn.Close()
+			},
+		),
+		&httplog.Options{Handler: slog.NewJSONHandler(&outputBuffer, nil)},
+	)
+
+	res := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), hijacked: false}
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !res.hijacked {
+		t.Error("expected hijack to be forwarded to the wrapped response writer")
+	}
+	logs := parseLogs(t, &outputBuffer)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d:\n%s", len(logs), outputBuffer.String())
+	}
+	assertField(t, logs[0], "status", float64(101))
+}
+
+func TestResponseWriterHijackNotSupported(t *testing.T) {
+	handler := httplog.Middleware(
+		http.HandlerFunc(
+			func(res http.ResponseWriter, _ *http.Request) {
+				_, _, err := res.(http.Hijacker).Hijack()
+				if !errors.Is(err, http.ErrNotSupported) {
+					t.Errorf("expected http.ErrNotSupported, got: %v", err)
+				}
+			},
+		),
+		&httplog.Options{Handler: slog.NewJSONHandler(io.Discard, nil)},
+	)
+
+	// httptest.ResponseRecorder does not implement http.Hijacker
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func parseLogs(t *testing.T, outputBuffer *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var logs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(outputBuffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var logLine map[string]any
+		if err := json.Unmarshal([]byte(line), &logLine); err != nil {
+			t.Fatalf("failed to parse log line '%s': %v", line, err)
+		}
+		logs = append(logs, logLine)
+	}
+	return logs
+}
+
+func assertField(t *testing.T, logLine map[string]any, key string, expected any) {
+	t.Helper()
+
+	if actual := logLine[key]; actual != expected {
+		t.Errorf("expected log field '%s' to be '%v', got '%v'", key, expected, actual)
+	}
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (recorder *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	recorder.hijacked = true
+	conn, otherEnd := net.Pipe()
+	_ = otherEnd.Close()
+	return conn, bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), nil
+}
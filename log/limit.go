@@ -0,0 +1,197 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"hermannm.dev/devlog/internal/limits"
+)
+
+// LimitOptions configure the handler returned by [log.LimitHandler]. A limit of 0 means no limit.
+type LimitOptions struct {
+	// MaxStringLength is the maximum length in bytes of string attribute values (including error
+	// messages, and strings in values that are marshaled to JSON). Longer strings are cut off,
+	// followed by a marker with the size of the truncated part, such as "…(truncated 12.3 KiB)".
+	MaxStringLength int
+
+	// MaxJSONDepth is the maximum nesting depth of objects and arrays in attribute values that are
+	// marshaled to JSON (such as structs, maps and slices). Deeper values are replaced by a
+	// truncation marker with their size. The attribute value itself has depth 1.
+	MaxJSONDepth int
+
+	// MaxArrayElements is the maximum number of elements of arrays in attribute values that are
+	// marshaled to JSON. Arrays with more elements are cut off, followed by a marker with the number
+	// of truncated elements.
+	MaxArrayElements int
+
+	// MaxRecordSize is the maximum total size in bytes of the message and attributes of a log
+	// record. If a record exceeds this, the attributes that don't fit are dropped, and a 'truncated'
+	// attribute is added with the size of the dropped attributes. The size is an estimate, since the
+	// exact output size depends on the format of the wrapped handler.
+	MaxRecordSize int
+}
+
+// LimitHandler wraps a [slog.Handler], truncating large attribute values before passing log
+// records to the wrapped handler. This lets you avoid huge log entries when logging big structs or
+// response bodies, which may be rejected by your log ingestion pipeline. It is meant for JSON
+// output (such as [slog.JSONHandler]): attribute values that are not strings or primitives are
+// marshaled to JSON and truncated, and passed on as [json.RawMessage] if they exceeded the limits.
+//
+// Example of how to set up your handler with this:
+//
+//	logHandler := log.LimitHandler(
+//		slog.NewJSONHandler(os.Stdout, nil),
+//		log.LimitOptions{MaxStringLength: 10_000, MaxArrayElements: 100, MaxRecordSize: 100_000},
+//	)
+//	log.SetDefault(logHandler)
+//
+// The devlog handler has the same limits in its own options (see the MaxStringLength field on
+// devlog.Options), so you don't need this for [hermannm.dev/devlog.Handler].
+//
+// LimitHandler panics if the given handler is nil.
+func LimitHandler(wrapped slog.Handler, options LimitOptions) slog.Handler {
+	if wrapped == nil {
+		panic("nil slog.Handler given to LimitHandler")
+	}
+
+	return limitHandler{
+		wrapped: wrapped,
+		limits: limits.Limits{
+			MaxStringLength:  options.MaxStringLength,
+			MaxJSONDepth:     options.MaxJSONDepth,
+			MaxArrayElements: options.MaxArrayElements,
+		},
+		maxRecordSize: options.MaxRecordSize,
+	}
+}
+
+type limitHandler struct {
+	wrapped       slog.Handler
+	limits        limits.Limits
+	maxRecordSize int
+}
+
+func (handler limitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.wrapped.Enabled(ctx, level)
+}
+
+func (handler limitHandler) Handle(ctx context.Context, record slog.Record) error {
+	limitedRecord := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+
+	recordSize := len(record.Message)
+	truncatedSize := 0
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			attr, attrSize := handler.limitAttr(attr, handler.maxRecordSize > 0)
+
+			if handler.maxRecordSize > 0 {
+				if truncatedSize > 0 || recordSize+attrSize > handler.maxRecordSize {
+					truncatedSize += attrSize
+					return true
+				}
+				recordSize += attrSize
+			}
+
+			limitedRecord.AddAttrs(attr)
+			return true
+		},
+	)
+
+	if truncatedSize > 0 {
+		limitedRecord.AddAttrs(
+			slog.String(truncatedAttrKey, limits.TruncationMarker(truncatedSize)),
+		)
+	}
+
+	return handler.wrapped.Handle(ctx, limitedRecord)
+}
+
+func (handler limitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	limitedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		limitedAttrs[i], _ = handler.limitAttr(attr, false)
+	}
+
+	return limitHandler{
+		wrapped:       handler.wrapped.WithAttrs(limitedAttrs),
+		limits:        handler.limits,
+		maxRecordSize: handler.maxRecordSize,
+	}
+}
+
+func (handler limitHandler) WithGroup(name string) slog.Handler {
+	return limitHandler{
+		wrapped:       handler.wrapped.WithGroup(name),
+		limits:        handler.limits,
+		maxRecordSize: handler.maxRecordSize,
+	}
+}
+
+// Limits the value of the given attribute. If estimateSize is true, also returns an estimate of the
+// output size of the limited attribute. This is done here instead of in a separate step, so that we
+// only marshal values to JSON once.
+func (handler limitHandler) limitAttr(
+	attr slog.Attr,
+	estimateSize bool,
+) (limited slog.Attr, size int) {
+	value := attr.Value.Resolve()
+	size = len(attr.Key)
+
+	switch value.Kind() {
+	case slog.KindString:
+		truncated := handler.truncateString(value.String())
+		return slog.String(attr.Key, truncated), size + len(truncated)
+	case slog.KindGroup:
+		groupAttrs := value.Group()
+		limitedAttrs := make([]slog.Attr, len(groupAttrs))
+		for i, groupAttr := range groupAttrs {
+			var groupAttrSize int
+			limitedAttrs[i], groupAttrSize = handler.limitAttr(groupAttr, estimateSize)
+			size += groupAttrSize
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(limitedAttrs...)}, size
+	case slog.KindAny:
+		switch anyValue := value.Any().(type) {
+		case string:
+			truncated := handler.truncateString(anyValue)
+			return slog.String(attr.Key, truncated), size + len(truncated)
+		case error:
+			// slog.JSONHandler outputs errors by their message
+			message := anyValue.Error()
+			if truncated := handler.truncateString(message); truncated != message {
+				return slog.String(attr.Key, truncated), size + len(truncated)
+			}
+			return slog.Attr{Key: attr.Key, Value: value}, size + len(message)
+		default:
+			if !handler.limits.LimitsJSON() && !estimateSize {
+				break
+			}
+			data, err := json.Marshal(anyValue)
+			if err != nil {
+				// Lets the wrapped handler deal with values that fail to marshal
+				break
+			}
+			if handler.limits.LimitsJSON() {
+				limitedData, err := limits.LimitJSON(data, handler.limits)
+				if err == nil && !bytes.Equal(limitedData, data) {
+					return slog.Any(attr.Key, json.RawMessage(limitedData)), size + len(limitedData)
+				}
+			}
+			return slog.Attr{Key: attr.Key, Value: value}, size + len(data)
+		}
+	default:
+	}
+
+	if estimateSize {
+		size += len(value.String())
+	}
+	return slog.Attr{Key: attr.Key, Value: value}, size
+}
+
+func (handler limitHandler) truncateString(value string) string {
+	return limits.TruncateString(value, handler.limits.MaxStringLength)
+}
+
+const truncatedAttrKey = "truncated"
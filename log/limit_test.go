@@ -0,0 +1,126 @@
+package log_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+func TestLimitHandler(t *testing.T) {
+	type user struct {
+		Name   string   `json:"name"`
+		Emails []string `json:"emails"`
+	}
+
+	logger, output := setupLimitLogger(log.LimitOptions{MaxStringLength: 5, MaxArrayElements: 1})
+
+	logger.
+		With("handlerAttr", "long handler value").
+		Error(
+			ctx,
+			errors.New("long error message"),
+			"Test",
+			"short", "value",
+			"user", user{Name: "Alice", Emails: []string{"alice@example.com", "alice@work.com"}},
+			slog.Group("group", "nested", "long nested value"),
+			"small", []int{1},
+		)
+
+	verifyLogOutput(
+		t,
+		output.String(),
+		"ERROR",
+		"Test",
+		`"handlerAttr":"long …(truncated 13 B)","cause":"long …(truncated 13 B)","short":"value",`+
+			`"user":{"name":"Alice","emails":["alice…(truncated 12 B)","…(truncated 1 element)"]},`+
+			`"group":{"nested":"long …(truncated 12 B)"},"small":[1]`,
+	)
+}
+
+func TestLimitHandlerJSONDepth(t *testing.T) {
+	logger, output := setupLimitLogger(log.LimitOptions{MaxJSONDepth: 1})
+
+	logger.Info(ctx, "Test", "nested", map[string]any{"key": map[string]any{"key": "value"}})
+
+	verifyLogAttrs(t, output.String(), `"nested":{"key":"…(truncated 15 B)"}`)
+}
+
+func TestLimitHandlerRecordSize(t *testing.T) {
+	logger, output := setupLimitLogger(log.LimitOptions{MaxRecordSize: 20})
+
+	logger.Info(ctx, "Test", "key1", "value1", "key2", "value2", "key3", "value3")
+
+	verifyLogAttrs(t, output.String(), `"key1":"value1","truncated":"…(truncated 20 B)"`)
+}
+
+func TestLimitHandlerMarshalsValueOnce(t *testing.T) {
+	logger, output := setupLimitLogger(log.LimitOptions{MaxArrayElements: 10, MaxRecordSize: 100})
+
+	value := &marshalCounter{}
+	logger.Info(ctx, "Test", "value", value)
+
+	verifyLogAttrs(t, output.String(), `"value":{"count":2}`)
+	// Once in LimitHandler (for both limits and size estimate), and once in the wrapped JSON handler
+	if value.count != 2 {
+		t.Errorf("Expected value to be marshaled 2 times, got %d", value.count)
+	}
+}
+
+type marshalCounter struct {
+	count int
+}
+
+func (counter *marshalCounter) MarshalJSON() ([]byte, error) {
+	counter.count++
+	return []byte(`{"count":` + strconv.Itoa(counter.count) + `}`), nil
+}
+
+func TestLimitHandlerWithinLimits(t *testing.T) {
+	logger, output := setupLimitLogger(
+		log.LimitOptions{
+			MaxStringLength:  100,
+			MaxJSONDepth:     10,
+			MaxArrayElements: 100,
+			MaxRecordSize:    1000,
+		},
+	)
+
+	logger.Info(ctx, "Test", "string", strings.Repeat("a", 10), "list", []int{1, 2, 3})
+
+	verifyLogAttrs(t, output.String(), `"string":"aaaaaaaaaa","list":[1,2,3]`)
+}
+
+func TestNilLimitHandler(t *testing.T) {
+	var panicValue any
+
+	passNilToLimitHandler := func() {
+		defer func() {
+			panicValue = recover()
+		}()
+
+		log.LimitHandler(nil, log.LimitOptions{})
+	}
+	passNilToLimitHandler()
+
+	expectedPanicValue := "nil slog.Handler given to LimitHandler"
+	if panicValue != expectedPanicValue {
+		t.Errorf(
+			`Unexpected panic value
+Want: %v
+ Got: %v`,
+			expectedPanicValue,
+			panicValue,
+		)
+	}
+}
+
+func setupLimitLogger(options log.LimitOptions) (log.Logger, *bytes.Buffer) {
+	var buffer bytes.Buffer
+	handler := slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return log.New(log.LimitHandler(handler, options)), &buffer
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"runtime"
+	"slices"
 	"time"
 )
 
@@ -722,6 +723,9 @@ func LogWithErrorsf(
 // The context parameter may be used by the log handler to determine whether the level is enabled.
 // If you're in a function without a context parameter, you may pass a nil context. But ideally, you
 // should pass a context wherever you do logging, in order to propagate context attributes.
+//
+// If a log level has been set on the context with [log.WithLevel], then that is used instead of
+// the handler's level.
 func Enabled(ctx context.Context, level slog.Level) bool {
 	return Default().Enabled(ctx, level)
 }
@@ -733,17 +737,26 @@ func Enabled(ctx context.Context, level slog.Level) bool {
 // every method.
 type Logger struct {
 	handler slog.Handler
+	// If nil, we use the system clock.
+	clock Clock
+}
+
+// Clock provides the current time, for the timestamps of log records. Set it on a logger with
+// [Logger.WithClock]. It's implemented by the fake clock in the
+// [hermannm.dev/devlog/log/logtest] subpackage.
+type Clock interface {
+	Now() time.Time
 }
 
 // New creates a Logger to produce structured log records for the given output handler.
 func New(outputHandler slog.Handler) Logger {
-	return Logger{handler: outputHandler}
+	return Logger{handler: outputHandler, clock: nil}
 }
 
 // Default creates a Logger with the same output handler as the one currently used by
 // [slog.Default].
 func Default() Logger {
-	return Logger{handler: slog.Default().Handler()}
+	return Logger{handler: slog.Default().Handler(), clock: nil}
 }
 
 // SetDefault is short-hand for calling:
@@ -784,7 +797,10 @@ func (logger Logger) With(logAttributes ...any) Logger {
 		return logger
 	}
 
-	return Logger{handler: logger.handler.WithAttrs(parseAttrs(nil, logAttributes))}
+	return Logger{
+		handler: logger.handler.WithAttrs(parseAttrs(nil, logAttributes)),
+		clock:   logger.clock,
+	}
 }
 
 // WithGroup returns a Logger that starts an attribute group.
@@ -795,7 +811,15 @@ func (logger Logger) WithGroup(name string) Logger {
 		return logger
 	}
 
-	return Logger{handler: logger.handler.WithGroup(name)}
+	return Logger{handler: logger.handler.WithGroup(name), clock: logger.clock}
+}
+
+// WithClock returns a Logger that uses the given clock to set the time of its log records, instead
+// of the system clock. This is useful in tests, to get deterministic timestamps in log output (see
+// the fake clock in the [hermannm.dev/devlog/log/logtest] subpackage). If clock is nil, the logger
+// uses the system clock.
+func (logger Logger) WithClock(clock Clock) Logger {
+	return Logger{handler: logger.handler, clock: clock}
 }
 
 // Handler returns the output handler for the logger.
@@ -1603,10 +1627,16 @@ func (logger Logger) LogWithErrorsf(
 // The context parameter may be used by the log handler to determine whether the level is enabled.
 // If you're in a function without a context parameter, you may pass a nil context. But ideally, you
 // should pass a context wherever you do logging, in order to propagate context attributes.
+//
+// If a log level has been set on the context with [log.WithLevel], then that is used instead of
+// the handler's level.
 func (logger Logger) Enabled(ctx context.Context, level slog.Level) bool {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if contextLevel, ok := getContextLevel(ctx); ok {
+		return level >= contextLevel
+	}
 	return logger.handler.Enabled(ctx, level)
 }
 
@@ -1623,16 +1653,48 @@ func (logger Logger) log(
 		ctx = context.Background()
 	}
 
-	if !logger.handler.Enabled(ctx, level) {
+	if !logger.Enabled(ctx, level) {
 		return
 	}
 
+	// Follows the example from the slog package for how to properly wrap its functions:
+	// https://pkg.go.dev/golang.org/x/exp/slog#hdr-Wrapping_output_methods
+	var programCounters [1]uintptr
+	// Skips 3, because we want to skip:
+	// - the call to runtime.Callers
+	// - the call to log (this function)
+	// - the call to the public log function that uses this function
+	runtime.Callers(3, programCounters[:])
+
+	logger.logWithSource(
+		ctx,
+		level,
+		programCounters[0],
+		message,
+		formatArgs,
+		parseAttrs(nil, logAttributes),
+		err,
+		errors,
+	)
+}
+
+// Expects the caller to have checked that the level is enabled, and to have replaced a nil context.
+// The given attributes must already be parsed with parseAttrs, and the slice must be owned by the
+// caller, since we append to it.
+func (logger Logger) logWithSource(
+	ctx context.Context,
+	level slog.Level,
+	programCounter uintptr,
+	message string,
+	formatArgs []any,
+	parsedAttrs []slog.Attr,
+	err error,
+	errors []error,
+) {
 	if len(formatArgs) != 0 {
 		message = fmt.Sprintf(message, formatArgs...)
 	}
 
-	parsedAttrs := parseAttrs(nil, logAttributes)
-
 	if err != nil {
 		if message == "" {
 			message, parsedAttrs = getErrorMessageAndCause(err, parsedAttrs)
@@ -1648,16 +1710,7 @@ func (logger Logger) log(
 	// ContextHandler
 	ctx = context.WithValue(ctx, contextAttrsKey, nil)
 
-	// Follows the example from the slog package for how to properly wrap its functions:
-	// https://pkg.go.dev/golang.org/x/exp/slog#hdr-Wrapping_output_methods
-	var programCounters [1]uintptr
-	// Skips 3, because we want to skip:
-	// - the call to runtime.Callers
-	// - the call to log (this function)
-	// - the call to the public log function that uses this function
-	runtime.Callers(3, programCounters[:])
-
-	record := slog.NewRecord(time.Now(), level, message, programCounters[0])
+	record := slog.NewRecord(logger.now(), level, message, programCounter)
 	if len(parsedAttrs) > 0 {
 		record.AddAttrs(parsedAttrs...)
 	}
@@ -1665,6 +1718,13 @@ func (logger Logger) log(
 	_ = logger.handler.Handle(ctx, record)
 }
 
+func (logger Logger) now() time.Time {
+	if logger.clock == nil {
+		return time.Now()
+	}
+	return logger.clock.Now()
+}
+
 // Adapted from the standard library:
 // https://github.com/golang/go/blob/ab5bd15941f3cea3695338756d0b8be0ef2321fb/src/log/slog/attr.go#L71
 func parseAttrs(parsed []slog.Attr, unparsed []any) []slog.Attr {
@@ -1703,13 +1763,67 @@ func appendAttr(attrs []slog.Attr, newAttr slog.Attr) []slog.Attr {
 	return append(attrs, newAttr)
 }
 
+// Appends the new attributes that don't have the same key as an existing attribute.
 func appendAttrs(attrs []slog.Attr, newAttrs []slog.Attr) []slog.Attr {
+	if len(newAttrs) == 0 {
+		return attrs
+	}
+
+	// For small numbers of attributes, scanning the slice is faster than building a key set
+	if len(attrs)+len(newAttrs) <= maxLinearAttrKeys {
+		for _, newAttr := range newAttrs {
+			attrs = appendAttr(attrs, newAttr)
+		}
+		return attrs
+	}
+
+	var keys attrKeySet
+	for _, attr := range attrs {
+		keys.add(attr.Key)
+	}
 	for _, newAttr := range newAttrs {
-		attrs = appendAttr(attrs, newAttr)
+		if !keys.contains(newAttr.Key) {
+			keys.add(newAttr.Key)
+			attrs = append(attrs, newAttr)
+		}
 	}
 	return attrs
 }
 
+// attrKeySet is a set of attribute keys, used to deduplicate attributes. Most logs have few
+// attributes, in which case scanning a slice is faster than a map, so we only switch to a map once
+// the set grows beyond maxLinearAttrKeys.
+type attrKeySet struct {
+	keys   []string
+	keyMap map[string]struct{}
+}
+
+func (set *attrKeySet) add(key string) {
+	if set.keyMap != nil {
+		set.keyMap[key] = struct{}{}
+		return
+	}
+
+	set.keys = append(set.keys, key)
+	if len(set.keys) > maxLinearAttrKeys {
+		set.keyMap = make(map[string]struct{}, len(set.keys)*2)
+		for _, key := range set.keys {
+			set.keyMap[key] = struct{}{}
+		}
+		set.keys = nil
+	}
+}
+
+func (set *attrKeySet) contains(key string) bool {
+	if set.keyMap != nil {
+		_, contains := set.keyMap[key]
+		return contains
+	}
+	return slices.Contains(set.keys, key)
+}
+
+const maxLinearAttrKeys = 16
+
 // Same key as the one the standard library uses for attributes that failed to parse:
 // https://github.com/golang/go/blob/ab5bd15941f3cea3695338756d0b8be0ef2321fb/src/log/slog/record.go#L160
 const badKey = "!BADKEY"
@@ -0,0 +1,51 @@
+package logtest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock with a manually controlled time, for deterministic timestamps in tests. It
+// implements both log.Clock (see log.Logger.WithClock) and [devlog.Clock] (see
+// [devlog.Options.Clock]):
+//
+//	clock := logtest.NewFakeClock(time.Date(2024, 9, 29, 10, 57, 30, 0, time.UTC))
+//	handler := devlog.NewHandler(&output, &devlog.Options{Clock: clock})
+//	logger := log.New(handler).WithClock(clock)
+//
+//	logger.Info(ctx, "First log") // [10:57:30] INFO: First log
+//	clock.Advance(90 * time.Second)
+//	logger.Info(ctx, "Second log") // [10:59:00] INFO: Second log
+//
+// A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewFakeClock creates a [FakeClock] that starts at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{lock: sync.Mutex{}, now: now}
+}
+
+// Now returns the current time of the clock. It only changes when calling [FakeClock.Advance] or
+// [FakeClock.Set].
+func (clock *FakeClock) Now() time.Time {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	return clock.now
+}
+
+// Advance moves the clock forward by the given duration.
+func (clock *FakeClock) Advance(duration time.Duration) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	clock.now = clock.now.Add(duration)
+}
+
+// Set sets the current time of the clock.
+func (clock *FakeClock) Set(now time.Time) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	clock.now = now
+}
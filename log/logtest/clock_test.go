@@ -0,0 +1,58 @@
+package logtest_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"hermannm.dev/devlog"
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/logtest"
+)
+
+func TestFakeClock(t *testing.T) {
+	clock := logtest.NewFakeClock(logtest.GoldenTime)
+	recorder := logtest.NewRecorder()
+	logger := log.New(recorder).WithClock(clock)
+
+	logger.Info(context.Background(), "First log")
+	clock.Advance(90 * time.Second)
+	logger.With("key", "value").Info(context.Background(), "Second log")
+
+	records := recorder.Records()
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d: %v", len(records), records)
+	}
+	if !records[0].Time.Equal(logtest.GoldenTime) {
+		t.Errorf("Expected first record at %v, got %v", logtest.GoldenTime, records[0].Time)
+	}
+	if expected := logtest.GoldenTime.Add(90 * time.Second); !records[1].Time.Equal(expected) {
+		t.Errorf("Expected second record at %v, got %v", expected, records[1].Time)
+	}
+}
+
+func TestFakeClockWithDevlogHandler(t *testing.T) {
+	clock := logtest.NewFakeClock(logtest.GoldenTime)
+
+	var output bytes.Buffer
+	logger := log.New(
+		devlog.NewHandler(
+			&output,
+			&devlog.Options{Clock: clock, TimeFormat: devlog.TimeFormatRelative},
+		),
+	).WithClock(clock)
+
+	logger.Info(context.Background(), "First log")
+	clock.Advance(time.Minute + 2*time.Second + 345*time.Millisecond)
+	logger.Info(context.Background(), "Second log")
+	clock.Advance(time.Hour)
+	logger.Info(context.Background(), "Third log")
+
+	expected := "[+00:00.000] INFO: First log\n" +
+		"[+01:02.345] INFO: Second log\n" +
+		"[+1:01:02.345] INFO: Third log\n"
+	if output.String() != expected {
+		t.Errorf("Unexpected log output\nWant: %q\n Got: %q", expected, output.String())
+	}
+}
@@ -0,0 +1,279 @@
+package logtest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/devlog"
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/logtest"
+)
+
+// The tests in this file check the devlog handler's output for logs from the devlog/log package.
+// They're here instead of in the devlog package, so that devlog doesn't depend on devlog/log (not
+// even in tests).
+
+// Snapshots the colored output of the handler for a representative set of logs. Run tests with
+// DEVLOG_UPDATE_GOLDEN=1 to update the golden file after changing the output format.
+func TestGoldenOutput(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Type string `json:"type"`
+	}
+
+	logtest.AssertGolden(
+		t,
+		"handler",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Debug("Debug message", "key", "value")
+			logger.Info("Info message", "number", 1, "bool", true)
+			logger.Warn("Warn message", slog.Group("group", "key1", "value1", "key2", 2))
+			logger.Error("Error message", "list", []string{"item1", "item2"})
+			logger.Info(
+				"Struct attribute",
+				"event",
+				event{ID: 1000, Type: "ACTION"},
+			)
+			logger.With("loggerKey", "value").WithGroup("loggerGroup").Info("Logger attributes")
+
+			log.New(logger.Handler()).Error(
+				context.Background(),
+				wrappedErrors{"request failed", []error{errors.New("connection refused")}},
+				"Error with cause",
+			)
+		},
+	)
+}
+
+func TestStructuredCauseError(t *testing.T) {
+	err := fmt.Errorf(
+		"failed to register user: %w",
+		wrappedErrors{
+			"invalid user data",
+			[]error{
+				wrappedErrors{"invalid email", []error{errors.New("missing @")}},
+				fmt.Errorf("invalid username: %w", errorWithDetails{"exceeds 30 characters"}),
+			},
+		},
+	)
+	errs := []error{err, fmt.Errorf("failed to send email: %w", errors.New("timeout"))}
+
+	// The structured cause format should be displayed the same way as the default format
+	for _, logFunc := range []func(logger log.Logger){
+		func(logger log.Logger) { logger.Error(context.Background(), err, "Test") },
+		func(logger log.Logger) { logger.Errors(context.Background(), errs, "Test") },
+	} {
+		log.SetErrorOptions(log.ErrorOptions{IncludeErrorTypes: true, IncludeErrorLogValues: true})
+		defaultOutput := getDevlogOutput(logFunc)
+
+		log.SetErrorOptions(
+			log.ErrorOptions{
+				IncludeErrorTypes:     true,
+				IncludeErrorLogValues: true,
+				StructuredCause:       true,
+			},
+		)
+		structuredOutput := getDevlogOutput(logFunc)
+
+		log.SetErrorOptions(log.ErrorOptions{})
+
+		if structuredOutput != defaultOutput {
+			t.Errorf(
+				`Unexpected output with structured cause
+Want:
+----------------------------------------
+%s
+----------------------------------------
+Got:
+----------------------------------------
+%s
+----------------------------------------`,
+				defaultOutput,
+				structuredOutput,
+			)
+		}
+	}
+}
+
+func TestMessageTemplate(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := log.New(
+		devlog.NewHandler(
+			&buffer,
+			&devlog.Options{ForceColors: true, TimeFormat: devlog.TimeFormatNone},
+		),
+	)
+
+	logger.Infot(context.Background(), "User {userId} logged in {{at}} {missing}", "userId", 1234)
+
+	assertContains(
+		t,
+		buffer.String(),
+		"INFO\x1b[0m\x1b[37m:\x1b[0m User \x1b[36m1234\x1b[0m logged in {at} {missing}\n",
+	)
+	// The message template should not be repeated as an attribute
+	if strings.Contains(buffer.String(), "messageTemplate") {
+		t.Errorf("Expected messageTemplate attribute to be omitted, got: %q", buffer.String())
+	}
+}
+
+func TestSyntaxHighlighting(t *testing.T) {
+	logtest.AssertGolden(
+		t,
+		"syntax_highlighting",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Info(
+				"Multi-line strings",
+				"json", "{\n  \"key\": \"value: \\\"quoted\\\"\",\n  \"list\": [1, true, null]\n}",
+				"sql", "SELECT id, name\nFROM users -- All users\nWHERE name = 'select from'",
+				"plain", "Not SQL or JSON\n[10:57:30] Looks like JSON",
+				"notSQL", "Update from the server\nCreated 2 users",
+				"invalidJSON", "{\n  invalid\n}",
+			)
+		},
+	)
+
+	// Syntax highlighting should be disabled by the option
+	logtest.AssertGolden(
+		t,
+		"syntax_highlighting_disabled",
+		&devlog.Options{DisableSyntaxHighlighting: true},
+		func(logger *slog.Logger) {
+			logger.Info("Multi-line SQL", "sql", "SELECT id\nFROM users")
+		},
+	)
+}
+
+func TestDiff(t *testing.T) {
+	type user struct {
+		Name  string   `json:"name"`
+		Email string   `json:"email,omitempty"`
+		Phone string   `json:"phone,omitempty"`
+		Tags  []string `json:"tags"`
+	}
+	before := user{Name: "Alice", Email: "", Phone: "12345678", Tags: []string{"admin"}}
+	after := user{Name: "Bob", Email: "bob@example.com", Phone: "", Tags: []string{"admin"}}
+
+	logtest.AssertGolden(
+		t,
+		"diff",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Info("Updated user", log.Diff("changes", before, after))
+			logger.Info("Nested diff", slog.Group("group", log.Diff("changes", 1, 2)))
+			logger.Info("No changes", log.Diff("changes", before, before))
+
+			// Diffs in context attributes should also be shown as diffs
+			ctx := log.AddContextAttrs(context.Background(), log.Diff("changes", "a", "b"))
+			log.New(logger.Handler()).Info(ctx, "Diff in context")
+		},
+	)
+}
+
+func TestTable(t *testing.T) {
+	type user struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Email string   `json:"email,omitempty"`
+		Tags  []string `json:"tags"`
+	}
+	users := []user{
+		{ID: 1, Name: "Alice", Email: "alice@example.com", Tags: []string{"admin"}},
+		{ID: 2, Name: "Bob", Email: "", Tags: nil},
+		{ID: 3, Name: "Charlie\nNewline", Email: "charlie.with.a.long.address@example.com", Tags: nil},
+	}
+
+	logtest.AssertGolden(
+		t,
+		"table",
+		&devlog.Options{RenderTables: true, MaxTableColumnWidth: 20, MaxArrayElements: 3},
+		func(logger *slog.Logger) {
+			logger.Info("Slice of structs", "users", users)
+			logger.Info("Truncated rows", "users", append(users, user{ID: 4, Name: "Dave"}))
+			logger.Info(
+				"Slice of maps",
+				"rows",
+				[]map[string]any{{"key": "a", "value": 1}, {"key": "b", "value": 2}},
+			)
+			logger.Info("Table in group", slog.Group("group", "users", users[:1]))
+			// Slices of structs that don't marshal to objects should fall back to JSON
+			logger.Info("Not objects", "times", []time.Time{time.Unix(0, 0).UTC()})
+		},
+	)
+
+	logtest.AssertGolden(
+		t,
+		"table_forced",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Info("Forced table", log.Table("users", users))
+			logger.Info("Not a table", log.Table("numbers", []int{1, 2, 3}))
+			logger.Info("Slice without RenderTables", "users", users[:1])
+			// Trailing spaces in the last cell should be kept, while empty cells at the end of a row
+			// should not add trailing whitespace
+			logger.Info(
+				"Trailing spaces",
+				log.Table("rows", []map[string]any{{"a": "1", "b": "x  "}, {"a": "2", "b": ""}}),
+			)
+
+			// Tables in context attributes should also be shown as tables
+			ctx := log.AddContextAttrs(context.Background(), log.Table("users", users[:1]))
+			log.New(logger.Handler()).Info(ctx, "Table in context")
+		},
+	)
+}
+
+// Implements the WrappingMessage() method that the devlog/log package uses to unwrap errors.
+type wrappedErrors struct {
+	msg    string
+	causes []error
+}
+
+func (err wrappedErrors) Error() string {
+	return fmt.Sprintf("%s: %v", err.msg, errors.Join(err.causes...))
+}
+
+func (err wrappedErrors) WrappingMessage() string {
+	return err.msg
+}
+
+func (err wrappedErrors) Unwrap() []error {
+	return err.causes
+}
+
+// Implements slog.LogValuer, which the devlog/log package uses to add details to errors when
+// log.ErrorOptions.IncludeErrorLogValues is set.
+type errorWithDetails struct {
+	msg string
+}
+
+func (err errorWithDetails) Error() string {
+	return err.msg
+}
+
+// Attributes are sorted by key, since the default cause format sorts error details by key, while
+// the structured format keeps their order (and we compare the two in TestStructuredCauseError).
+func (err errorWithDetails) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("field", "username"), slog.Int("maxLength", 30))
+}
+
+// Logs with a devlog handler without colors or time, and returns the output.
+func getDevlogOutput(logFunc func(logger log.Logger)) string {
+	var buffer bytes.Buffer
+	logger := log.New(
+		devlog.NewHandler(
+			&buffer,
+			&devlog.Options{DisableColors: true, TimeFormat: devlog.TimeFormatNone},
+		),
+	)
+	logFunc(logger)
+	return buffer.String()
+}
@@ -0,0 +1,185 @@
+package logtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/devlog"
+)
+
+// If this environment variable is set to a non-empty value, [AssertGolden] updates golden files
+// instead of comparing against them. We use an environment variable instead of a test flag, since
+// flags are global, so registering one here would conflict with flags of the same name in packages
+// that import this.
+const updateGoldenEnvVar = "DEVLOG_UPDATE_GOLDEN"
+
+// GoldenTime is the fixed time that [AssertGolden] sets on all log records, so that golden files
+// don't change between test runs.
+var GoldenTime = time.Date(2024, 9, 29, 10, 57, 30, 0, time.UTC)
+
+// AssertGolden renders the logs made by logFunc through a [devlog.Handler] with the given options,
+// and compares the output against the golden file at testdata/<name>.golden (relative to the test's
+// package directory). This lets you snapshot the output of the handler, for example to test custom
+// formatting of your own types.
+//
+// To make the output stable and readable:
+//   - All log records get the time in [GoldenTime]
+//   - Colors are enabled, unless [devlog.Options.DisableColors] is set, and the color escape codes
+//     are replaced with tags like <cyan> and <reset>, so that you can see them in the golden file
+//
+// If options is nil, the default options are used, except that [devlog.Options.Level] defaults to
+// DEBUG.
+//
+// To create or update golden files, run your tests with the DEVLOG_UPDATE_GOLDEN environment
+// variable set:
+//
+//	DEVLOG_UPDATE_GOLDEN=1 go test ./...
+func AssertGolden(
+	t testing.TB,
+	name string,
+	options *devlog.Options,
+	logFunc func(logger *slog.Logger),
+) {
+	t.Helper()
+
+	var handlerOptions devlog.Options
+	if options != nil {
+		handlerOptions = *options
+	}
+	if handlerOptions.Level == nil {
+		handlerOptions.Level = slog.LevelDebug
+	}
+	if !handlerOptions.DisableColors {
+		handlerOptions.ForceColors = true
+	}
+
+	var output bytes.Buffer
+	handler := fixedTimeHandler{devlog.NewHandler(&output, &handlerOptions)}
+	logFunc(slog.New(handler))
+
+	actual := visualizeColors(output.String())
+	goldenFile := filepath.Join("testdata", name+".golden")
+
+	if os.Getenv(updateGoldenEnvVar) != "" {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o750); err != nil {
+			t.Fatalf("Failed to create directory for golden file '%s': %v", goldenFile, err)
+		}
+		if err := os.WriteFile(goldenFile, []byte(actual), 0o600); err != nil {
+			t.Fatalf("Failed to update golden file '%s': %v", goldenFile, err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf(
+			"Failed to read golden file '%s' (run tests with %s=1 to create it): %v",
+			goldenFile,
+			updateGoldenEnvVar,
+			err,
+		)
+	}
+
+	if actual != string(expected) {
+		t.Errorf(
+			"Log output does not match golden file '%s' (run tests with %s=1 to update it)\n%s",
+			goldenFile,
+			updateGoldenEnvVar,
+			describeMismatch(string(expected), actual),
+		)
+	}
+}
+
+// fixedTimeHandler wraps a handler, replacing the time of each log record with [GoldenTime].
+type fixedTimeHandler struct {
+	wrapped slog.Handler
+}
+
+func (handler fixedTimeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.wrapped.Enabled(ctx, level)
+}
+
+func (handler fixedTimeHandler) Handle(ctx context.Context, record slog.Record) error {
+	// Follows the slog convention of not outputting time for records with zero time
+	if !record.Time.IsZero() {
+		record.Time = GoldenTime
+	}
+	return handler.wrapped.Handle(ctx, record)
+}
+
+func (handler fixedTimeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return fixedTimeHandler{handler.wrapped.WithAttrs(attrs)}
+}
+
+func (handler fixedTimeHandler) WithGroup(name string) slog.Handler {
+	return fixedTimeHandler{handler.wrapped.WithGroup(name)}
+}
+
+var colorEscapeCode = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// Names of the ANSI color codes (https://en.wikipedia.org/wiki/ANSI_escape_code#Colors). Should
+// include all the colors in devlog's color.go. Unknown codes are shown by number.
+var colorNames = map[string]string{
+	"0":  "reset",
+	"1":  "bold",
+	"30": "black",
+	"31": "red",
+	"32": "green",
+	"33": "yellow",
+	"34": "blue",
+	"35": "magenta",
+	"36": "cyan",
+	"37": "gray",
+}
+
+// Replaces color escape codes in the given log output with tags like <cyan>.
+func visualizeColors(output string) string {
+	return colorEscapeCode.ReplaceAllStringFunc(
+		output,
+		func(escapeCode string) string {
+			code := colorEscapeCode.FindStringSubmatch(escapeCode)[1]
+			if name, ok := colorNames[code]; ok {
+				return "<" + name + ">"
+			}
+			return "<color:" + code + ">"
+		},
+	)
+}
+
+// Describes the first line where the expected and actual output differ, along with the full
+// output.
+func describeMismatch(expected string, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+
+	lineNumber := 0
+	for lineNumber < len(expectedLines) &&
+		lineNumber < len(actualLines) &&
+		expectedLines[lineNumber] == actualLines[lineNumber] {
+		lineNumber++
+	}
+
+	var expectedLine, actualLine string
+	if lineNumber < len(expectedLines) {
+		expectedLine = expectedLines[lineNumber]
+	}
+	if lineNumber < len(actualLines) {
+		actualLine = actualLines[lineNumber]
+	}
+
+	return fmt.Sprintf(
+		"First difference at line %d:\nWant: %q\n Got: %q\n\nFull output:\n%s",
+		lineNumber+1,
+		expectedLine,
+		actualLine,
+		actual,
+	)
+}
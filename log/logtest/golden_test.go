@@ -0,0 +1,64 @@
+package logtest_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"hermannm.dev/devlog"
+	"hermannm.dev/devlog/log/logtest"
+)
+
+func TestAssertGolden(t *testing.T) {
+	logtest.AssertGolden(
+		t,
+		"golden",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Info("Test", "key", "value", slog.Group("group", "groupKey", 1))
+			logger.Debug("Debug log")
+		},
+	)
+}
+
+func TestAssertGoldenWithoutColors(t *testing.T) {
+	logtest.AssertGolden(
+		t,
+		"golden_without_colors",
+		&devlog.Options{DisableColors: true, Level: slog.LevelInfo},
+		func(logger *slog.Logger) {
+			logger.Info("Test", "key", "value")
+			logger.Debug("Not included, since level is INFO")
+		},
+	)
+}
+
+func TestAssertGoldenMismatch(t *testing.T) {
+	// We don't want to overwrite the golden file with the mismatched output
+	if os.Getenv("DEVLOG_UPDATE_GOLDEN") != "" {
+		t.Skip("Skipping golden file mismatch test when updating golden files")
+	}
+
+	fakeT := &fakeTestingT{TB: t, errors: nil, logs: nil, cleanups: nil}
+
+	logtest.AssertGolden(
+		fakeT,
+		"golden",
+		nil,
+		func(logger *slog.Logger) {
+			logger.Error("Unexpected log", "cause", errors.New("something went wrong"))
+		},
+	)
+
+	if len(fakeT.errors) != 1 {
+		t.Fatalf("Expected AssertGolden to fail, got errors: %v", fakeT.errors)
+	}
+	assertContains(
+		t,
+		fakeT.errors[0],
+		"Log output does not match golden file 'testdata/golden.golden'",
+		"First difference at line 1:",
+		`Want: "<gray>[10:57:30]<reset> <green>INFO<reset><gray>:<reset> Test"`,
+	)
+}
@@ -0,0 +1,362 @@
+// Package logtest provides utilities for testing code that logs. Instead of parsing log output,
+// you can use a [Recorder] to capture log records, and assert on their messages and attributes:
+//
+//	func TestCreateUser(t *testing.T) {
+//		recorder := logtest.Setup(t)
+//
+//		createUser(ctx, "Alice")
+//
+//		recorder.AssertLogged(t, slog.LevelInfo, "Created user", "user.name", "Alice")
+//	}
+//
+// [Setup] also routes logs to [testing.T.Log], formatted with [devlog.Handler], so that logs from
+// the test are shown if it fails (or if you run tests with -v). If you only want that part, use
+// [TestHandler].
+//
+// If you want to test how logs are formatted by [devlog.Handler] (for example, if your types
+// implement [slog.LogValuer]), you can use [AssertGolden] to compare the output against a golden
+// file.
+package logtest
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hermannm.dev/devlog"
+	"hermannm.dev/devlog/log"
+)
+
+// Setup creates a [Recorder] and sets it as the default logger (see [log.SetDefault]) for the
+// duration of the test, restoring the previous default logger when the test completes. Logs are
+// also written to [testing.T.Log] (see [TestHandler]), so that they're shown if the test fails.
+//
+// Since this changes the global default logger, it should not be used in parallel tests. For those,
+// you can instead create a Recorder with [NewRecorder], and pass it to [log.New].
+func Setup(t testing.TB) *Recorder {
+	t.Helper()
+
+	recorder := NewRecorder()
+	handler := teeHandler{
+		handlers: []slog.Handler{
+			recorder,
+			TestHandler(t, &devlog.Options{Level: slog.LevelDebug, DisableColors: true}),
+		},
+	}
+
+	previousDefault := slog.Default()
+	log.SetDefault(handler)
+	t.Cleanup(
+		func() {
+			slog.SetDefault(previousDefault)
+		},
+	)
+
+	return recorder
+}
+
+// Recorder is a [slog.Handler] that captures log records in memory, so that tests can assert on
+// them. It records logs at all levels.
+//
+// Attributes are flattened when recorded: attributes added with [slog.Logger.With] are included in
+// each record, and attributes in groups get the group names as a prefix to their keys, separated by
+// a dot (so an attribute "name" in a group "user" gets the key "user.name"). Values that implement
+// [slog.LogValuer] are resolved.
+//
+// A Recorder is safe for concurrent use. Handlers derived from it with WithAttrs and WithGroup
+// record to the same Recorder.
+type Recorder struct {
+	state *recorderState
+
+	attrs       []slog.Attr
+	groupPrefix string
+}
+
+type recorderState struct {
+	lock    sync.Mutex
+	records []Record
+}
+
+// Record is a log record captured by a [Recorder].
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Flattened attributes of the record (see [Recorder]), in the order they were added.
+	Attrs []slog.Attr
+}
+
+// NewRecorder creates a new [Recorder] with no records.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		state:       &recorderState{lock: sync.Mutex{}, records: nil},
+		attrs:       nil,
+		groupPrefix: "",
+	}
+}
+
+// Records returns a copy of the records captured by the recorder, in the order they were logged.
+func (recorder *Recorder) Records() []Record {
+	recorder.state.lock.Lock()
+	defer recorder.state.lock.Unlock()
+	return slices.Clone(recorder.state.records)
+}
+
+// Reset removes all records captured by the recorder.
+func (recorder *Recorder) Reset() {
+	recorder.state.lock.Lock()
+	defer recorder.state.lock.Unlock()
+	recorder.state.records = nil
+}
+
+// AssertLogged checks that the recorder has captured a record with the given level and message,
+// which includes the given attributes. It fails the test if no such record is found, listing the
+// records that were captured.
+//
+// Expected attributes are passed in the same way as to the log functions in [log]: either as pairs
+// of string keys and corresponding values, or as [slog.Attr] objects. Keys of attributes in groups
+// should be prefixed by the group name (see [Recorder]). The record may have other attributes than
+// the expected ones.
+func (recorder *Recorder) AssertLogged(
+	t testing.TB,
+	level slog.Level,
+	message string,
+	expectedAttributes ...any,
+) {
+	t.Helper()
+
+	expectedAttrs := parseAttrs(expectedAttributes)
+	records := recorder.Records()
+	for _, record := range records {
+		if record.Level == level && record.Message == message && record.hasAttrs(expectedAttrs) {
+			return
+		}
+	}
+
+	var recordList strings.Builder
+	for _, record := range records {
+		recordList.WriteString("\n\t")
+		recordList.WriteString(record.String())
+	}
+	if len(records) == 0 {
+		recordList.WriteString("\n\t(none)")
+	}
+
+	t.Errorf(
+		"Expected log record not found\nWant: %s\nRecorded logs:%s",
+		Record{Time: time.Time{}, Level: level, Message: message, Attrs: expectedAttrs}.String(),
+		recordList.String(),
+	)
+}
+
+// Attr returns the value of the attribute with the given key in the record, if found. Keys of
+// attributes in groups are prefixed by the group name (see [Recorder]).
+func (record Record) Attr(key string) (value slog.Value, found bool) {
+	for _, attr := range record.Attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// String formats the record's level, message and attributes, for use in test failure messages.
+func (record Record) String() string {
+	var builder strings.Builder
+	builder.WriteString(record.Level.String())
+	builder.WriteString(": ")
+	builder.WriteString(record.Message)
+	for _, attr := range record.Attrs {
+		builder.WriteByte(' ')
+		builder.WriteString(attr.String())
+	}
+	return builder.String()
+}
+
+func (record Record) hasAttrs(expectedAttrs []slog.Attr) bool {
+	for _, expected := range expectedAttrs {
+		actual, found := record.Attr(expected.Key)
+		if !found || !reflect.DeepEqual(actual.Any(), expected.Value.Resolve().Any()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Enabled implements [slog.Handler]. A Recorder records logs at all levels.
+func (recorder *Recorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements [slog.Handler], capturing the given record.
+func (recorder *Recorder) Handle(_ context.Context, record slog.Record) error {
+	attrs := slices.Clone(recorder.attrs)
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			attrs = appendFlattenedAttr(attrs, attr, recorder.groupPrefix)
+			return true
+		},
+	)
+
+	recorder.state.lock.Lock()
+	defer recorder.state.lock.Unlock()
+	recorder.state.records = append(
+		recorder.state.records,
+		Record{Time: record.Time, Level: record.Level, Message: record.Message, Attrs: attrs},
+	)
+	return nil
+}
+
+// WithAttrs implements [slog.Handler].
+func (recorder *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	newAttrs := slices.Clip(recorder.attrs)
+	for _, attr := range attrs {
+		newAttrs = appendFlattenedAttr(newAttrs, attr, recorder.groupPrefix)
+	}
+
+	return &Recorder{state: recorder.state, attrs: newAttrs, groupPrefix: recorder.groupPrefix}
+}
+
+// WithGroup implements [slog.Handler].
+func (recorder *Recorder) WithGroup(name string) slog.Handler {
+	// Follows the slog convention of ignoring groups with empty names
+	if name == "" {
+		return recorder
+	}
+
+	return &Recorder{
+		state:       recorder.state,
+		attrs:       recorder.attrs,
+		groupPrefix: recorder.groupPrefix + name + ".",
+	}
+}
+
+func appendFlattenedAttr(attrs []slog.Attr, attr slog.Attr, groupPrefix string) []slog.Attr {
+	value := attr.Value.Resolve()
+
+	if value.Kind() != slog.KindGroup {
+		// Follows the slog convention of ignoring empty attributes
+		if attr.Key == "" && value.Any() == nil {
+			return attrs
+		}
+		return append(attrs, slog.Attr{Key: groupPrefix + attr.Key, Value: value})
+	}
+
+	// Follows the slog convention of inlining groups with empty keys
+	if attr.Key != "" {
+		groupPrefix = groupPrefix + attr.Key + "."
+	}
+	for _, groupAttr := range value.Group() {
+		attrs = appendFlattenedAttr(attrs, groupAttr, groupPrefix)
+	}
+	return attrs
+}
+
+// TestHandler returns a [slog.Handler] that formats logs with [devlog.Handler], and writes them to
+// [testing.T.Log]. This means that logs are only shown if the test fails (or if you run tests with
+// the -v flag), and that they're shown under the test that made them.
+//
+// Logs made after the test has completed (for example, from a goroutine that outlives the test)
+// are discarded, since calling t.Log after a test completes panics.
+//
+// The options are passed to [devlog.NewHandler]. If options is nil, the default options are used.
+func TestHandler(t testing.TB, options *devlog.Options) slog.Handler {
+	writer := &testWriter{t: t, lock: sync.Mutex{}, done: false}
+	t.Cleanup(writer.close)
+	return devlog.NewHandler(writer, options)
+}
+
+type testWriter struct {
+	t    testing.TB
+	lock sync.Mutex
+	done bool
+}
+
+func (writer *testWriter) Write(output []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	if !writer.done {
+		writer.t.Helper()
+		writer.t.Log(strings.TrimSuffix(string(output), "\n"))
+	}
+	return len(output), nil
+}
+
+func (writer *testWriter) close() {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	writer.done = true
+}
+
+// teeHandler forwards logs to multiple handlers.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (handler teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, wrapped := range handler.handlers {
+		if wrapped.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (handler teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, wrapped := range handler.handlers {
+		if wrapped.Enabled(ctx, record.Level) {
+			if err := wrapped.Handle(ctx, record.Clone()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (handler teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(handler.handlers))
+	for _, wrapped := range handler.handlers {
+		handlers = append(handlers, wrapped.WithAttrs(attrs))
+	}
+	return teeHandler{handlers}
+}
+
+func (handler teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(handler.handlers))
+	for _, wrapped := range handler.handlers {
+		handlers = append(handlers, wrapped.WithGroup(name))
+	}
+	return teeHandler{handlers}
+}
+
+// Adapted from parseAttrs in the log package, which is adapted from the standard library:
+// https://github.com/golang/go/blob/ab5bd15941f3cea3695338756d0b8be0ef2321fb/src/log/slog/attr.go#L71
+func parseAttrs(unparsed []any) []slog.Attr {
+	var parsed []slog.Attr
+
+	for len(unparsed) > 0 {
+		switch attr := unparsed[0].(type) {
+		case slog.Attr:
+			parsed, unparsed = append(parsed, attr), unparsed[1:]
+		case string:
+			if len(unparsed) == 1 {
+				parsed, unparsed = append(parsed, slog.String(badKey, attr)), nil
+			} else {
+				parsed, unparsed = append(parsed, slog.Any(attr, unparsed[1])), unparsed[2:]
+			}
+		default:
+			parsed, unparsed = append(parsed, slog.Any(badKey, attr)), unparsed[1:]
+		}
+	}
+
+	return parsed
+}
+
+// Same key as the log package uses for attributes with missing keys.
+const badKey = "!BADKEY"
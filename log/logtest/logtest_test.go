@@ -0,0 +1,177 @@
+package logtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog"
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/logtest"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	logger := log.New(recorder)
+
+	logger.
+		With("handlerKey", "value1").
+		WithGroup("group").
+		Info(
+			context.Background(),
+			"Test",
+			"logKey", 2,
+			slog.Group("subGroup", "subKey", true),
+			slog.Group("", "inlinedKey", "value3"),
+		)
+
+	records := recorder.Records()
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %d: %v", len(records), records)
+	}
+	record := records[0]
+
+	if record.Level != slog.LevelInfo || record.Message != "Test" {
+		t.Errorf("Unexpected record level and message: %v", record)
+	}
+
+	expectedAttrs := []slog.Attr{
+		slog.String("handlerKey", "value1"),
+		slog.Int("group.logKey", 2),
+		slog.Bool("group.subGroup.subKey", true),
+		slog.String("group.inlinedKey", "value3"),
+	}
+	if !reflect.DeepEqual(record.Attrs, expectedAttrs) {
+		t.Errorf("Unexpected record attributes\nWant: %v\n Got: %v", expectedAttrs, record.Attrs)
+	}
+
+	recorder.Reset()
+	if records := recorder.Records(); len(records) != 0 {
+		t.Errorf("Expected no records after reset, got %v", records)
+	}
+}
+
+func TestAssertLogged(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	logger := log.New(recorder)
+
+	ctx := log.AddContextAttrs(context.Background(), "contextKey", "value")
+	logger.Error(ctx, errors.New("something went wrong"), "Request failed", "status", 500)
+
+	recorder.AssertLogged(t, slog.LevelError, "Request failed")
+	recorder.AssertLogged(
+		t,
+		slog.LevelError,
+		"Request failed",
+		"status", 500,
+		slog.String("cause", "something went wrong"),
+		"contextKey", "value",
+	)
+
+	for _, testCase := range []struct {
+		name    string
+		level   slog.Level
+		message string
+		attrs   []any
+	}{
+		{"wrong level", slog.LevelInfo, "Request failed", nil},
+		{"wrong message", slog.LevelError, "Request succeeded", nil},
+		{"wrong attribute value", slog.LevelError, "Request failed", []any{"status", 200}},
+		{"missing attribute", slog.LevelError, "Request failed", []any{"missingKey", "value"}},
+	} {
+		t.Run(
+			testCase.name,
+			func(t *testing.T) {
+				fakeT := &fakeTestingT{TB: t, errors: nil, logs: nil, cleanups: nil}
+				recorder.AssertLogged(fakeT, testCase.level, testCase.message, testCase.attrs...)
+
+				if len(fakeT.errors) != 1 {
+					t.Fatalf("Expected AssertLogged to fail, got errors: %v", fakeT.errors)
+				}
+				assertContains(
+					t,
+					fakeT.errors[0],
+					"Expected log record not found",
+					"Recorded logs:\n\tERROR: Request failed cause=something went wrong status=500",
+				)
+			},
+		)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	previousDefault := slog.Default()
+
+	t.Run(
+		"subtest",
+		func(t *testing.T) {
+			recorder := logtest.Setup(t)
+
+			log.Debug(context.Background(), "Test", "key", "value")
+			recorder.AssertLogged(t, slog.LevelDebug, "Test", "key", "value")
+		},
+	)
+
+	if slog.Default() != previousDefault {
+		t.Error("Expected previous default logger to be restored after test completed")
+	}
+}
+
+func TestTestHandler(t *testing.T) {
+	fakeT := &fakeTestingT{TB: t, errors: nil, logs: nil, cleanups: nil}
+	logger := log.New(logtest.TestHandler(fakeT, &devlog.Options{TimeFormat: devlog.TimeFormatNone}))
+
+	logger.Info(context.Background(), "Test", "key", "value")
+
+	expectedLogs := []string{"INFO: Test\n  key: value"}
+	if !reflect.DeepEqual(fakeT.logs, expectedLogs) {
+		t.Errorf("Unexpected test logs\nWant: %q\n Got: %q", expectedLogs, fakeT.logs)
+	}
+
+	// Logs made after the test has completed should be discarded
+	fakeT.runCleanups()
+	logger.Info(context.Background(), "After test")
+	if len(fakeT.logs) != 1 {
+		t.Errorf("Expected log after test completion to be discarded, got %q", fakeT.logs)
+	}
+}
+
+// Wraps testing.TB, to capture calls that would otherwise fail the test or write to its output.
+type fakeTestingT struct {
+	testing.TB
+	errors   []string
+	logs     []string
+	cleanups []func()
+}
+
+func (fakeT *fakeTestingT) Errorf(format string, args ...any) {
+	fakeT.errors = append(fakeT.errors, fmt.Sprintf(format, args...))
+}
+
+func (fakeT *fakeTestingT) Log(args ...any) {
+	fakeT.logs = append(fakeT.logs, fmt.Sprint(args...))
+}
+
+func (fakeT *fakeTestingT) Cleanup(cleanup func()) {
+	fakeT.cleanups = append(fakeT.cleanups, cleanup)
+}
+
+func (fakeT *fakeTestingT) runCleanups() {
+	for _, cleanup := range fakeT.cleanups {
+		cleanup()
+	}
+}
+
+func assertContains(t *testing.T, output string, expectedInOutput ...string) {
+	t.Helper()
+
+	for _, expected := range expectedInOutput {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain '%s', got: %s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+module hermannm.dev/devlog/log/otellog
+
+go 1.23.0
+
+require (
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+	hermannm.dev/devlog v0.7.0
+)
+
+// Uses the devlog module in this repository during development, so that changes to both modules can
+// be made together. Users of this module don't get this replace directive, so the devlog version
+// required above must be a released version with all the devlog APIs that this module uses. See
+// "Publishing a new release" in the README for the release order.
+replace hermannm.dev/devlog => ../..
@@ -0,0 +1,274 @@
+// Package otellog connects logs to [OpenTelemetry] traces. It provides a [slog.Handler] wrapper
+// that adds the trace and span IDs of the current span to every log record, and records ERROR logs
+// as events on the current span.
+//
+// Example of how to set it up:
+//
+//	log.SetDefault(otellog.Handler(devlog.NewHandler(os.Stdout, nil), nil))
+//
+// Logs made with a context that contains a span (from [go.opentelemetry.io/otel/trace]) will then
+// include 'trace_id' and 'span_id' attributes. The devlog handler shows these in a compact format
+// (shortened and dimmed), so that they don't take focus away from the rest of the log.
+//
+// This package is a separate Go module, so that users of devlog who don't use OpenTelemetry don't
+// get it as a dependency. Add it to your project with:
+//
+//	go get hermannm.dev/devlog/log/otellog
+//
+// [OpenTelemetry]: https://opentelemetry.io
+package otellog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options configure the log handler returned by [Handler].
+type Options struct {
+	// SpanEventLevel is the minimum level of log records that are recorded as events on the
+	// current span.
+	// If nil, defaults to [slog.LevelError].
+	SpanEventLevel slog.Leveler
+
+	// DisableSpanEvents stops log records from being recorded as span events, so that the handler
+	// only adds trace and span IDs to logs.
+	DisableSpanEvents bool
+}
+
+// Handler wraps a [slog.Handler], adding 'trace_id' and 'span_id' attributes from the
+// OpenTelemetry span in the log context (if any) before forwarding logs to the wrapped handler.
+//
+// In addition, log records at or above [Options.SpanEventLevel] (ERROR by default) are recorded as
+// events on the span, with the log message as the event name, and the log attributes as event
+// attributes (with keys of nested groups joined by '.'). This lets you see logs in the context of
+// their trace in your tracing tool.
+//
+// If options is nil, the default options are used.
+//
+// Handler panics if the given handler is nil.
+func Handler(wrapped slog.Handler, options *Options) slog.Handler {
+	if wrapped == nil {
+		panic("nil slog.Handler given to otellog.Handler")
+	}
+
+	handler := handler{
+		wrapped:         wrapped,
+		options:         Options{},
+		groupPrefix:     "",
+		eventAttributes: nil,
+	}
+	if options != nil {
+		handler.options = *options
+	}
+	if handler.options.SpanEventLevel == nil {
+		handler.options.SpanEventLevel = slog.LevelError
+	}
+
+	return handler
+}
+
+type handler struct {
+	wrapped slog.Handler
+	options Options
+
+	// Keys of attributes added to span events are prefixed with the current group names, joined by
+	// '.' (the convention for nested attributes in OpenTelemetry).
+	groupPrefix string
+	// Attributes from WithAttrs, which we need to add to span events ourselves, since they are only
+	// passed on to the wrapped handler.
+	eventAttributes []attribute.KeyValue
+}
+
+func (handler handler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if handler.shouldRecordSpanEvent(record.Level) {
+		if span := trace.SpanFromContext(ctx); span.IsRecording() {
+			handler.recordSpanEvent(span, record)
+		}
+	}
+
+	if !handler.wrapped.Enabled(ctx, record.Level) {
+		return nil
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		addAttrIfMissing(&record, slog.String(traceIDAttrKey, spanContext.TraceID().String()))
+		addAttrIfMissing(&record, slog.String(spanIDAttrKey, spanContext.SpanID().String()))
+	}
+
+	return handler.wrapped.Handle(ctx, record)
+}
+
+// Enabled reports whether the wrapped handler is enabled for the given level, or if the level
+// should be recorded as an event on the span in the given context.
+func (handler handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if handler.wrapped.Enabled(ctx, level) {
+		return true
+	}
+
+	if ctx == nil || !handler.shouldRecordSpanEvent(level) {
+		return false
+	}
+	return trace.SpanFromContext(ctx).IsRecording()
+}
+
+func (handler handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return handler
+	}
+
+	newHandler := handler
+	newHandler.wrapped = handler.wrapped.WithAttrs(attrs)
+	if !handler.options.DisableSpanEvents {
+		// Clip the slice, so that appending allocates a new array instead of mutating the array of
+		// the parent handler
+		newHandler.eventAttributes = slices.Clip(handler.eventAttributes)
+		for _, attr := range attrs {
+			newHandler.eventAttributes = appendEventAttribute(
+				newHandler.eventAttributes,
+				handler.groupPrefix,
+				attr,
+			)
+		}
+	}
+	return newHandler
+}
+
+func (handler handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+
+	newHandler := handler
+	newHandler.wrapped = handler.wrapped.WithGroup(name)
+	newHandler.groupPrefix = handler.groupPrefix + name + "."
+	return newHandler
+}
+
+func (handler handler) shouldRecordSpanEvent(level slog.Level) bool {
+	return !handler.options.DisableSpanEvents && level >= handler.options.SpanEventLevel.Level()
+}
+
+func (handler handler) recordSpanEvent(span trace.Span, record slog.Record) {
+	attributes := make(
+		[]attribute.KeyValue,
+		0,
+		len(handler.eventAttributes)+record.NumAttrs()+1,
+	)
+	attributes = append(attributes, attribute.String(severityEventAttrKey, record.Level.String()))
+	attributes = append(attributes, handler.eventAttributes...)
+	record.Attrs(
+		func(attr slog.Attr) bool {
+			attributes = appendEventAttribute(attributes, handler.groupPrefix, attr)
+			return true
+		},
+	)
+
+	eventOptions := []trace.EventOption{trace.WithAttributes(attributes...)}
+	if !record.Time.IsZero() {
+		eventOptions = append(eventOptions, trace.WithTimestamp(record.Time))
+	}
+
+	span.AddEvent(record.Message, eventOptions...)
+}
+
+// Converts the given log attribute to OpenTelemetry attributes. Groups are flattened, with their
+// keys joined by '.'.
+func appendEventAttribute(
+	attributes []attribute.KeyValue,
+	keyPrefix string,
+	attr slog.Attr,
+) []attribute.KeyValue {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) { //nolint:exhaustruct // Checking empty attr on purpose
+		return attributes
+	}
+
+	key := keyPrefix + attr.Key
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		// As in slog, groups with empty keys are inlined in the parent
+		groupPrefix := keyPrefix
+		if attr.Key != "" {
+			groupPrefix = key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			attributes = appendEventAttribute(attributes, groupPrefix, groupAttr)
+		}
+		return attributes
+	case slog.KindString:
+		return append(attributes, attribute.String(key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(attributes, attribute.Int64(key, attr.Value.Int64()))
+	case slog.KindUint64:
+		value := attr.Value.Uint64()
+		// OpenTelemetry does not have unsigned integer attributes
+		if value <= math.MaxInt64 {
+			return append(attributes, attribute.Int64(key, int64(value)))
+		}
+		return append(attributes, attribute.String(key, attr.Value.String()))
+	case slog.KindFloat64:
+		return append(attributes, attribute.Float64(key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(attributes, attribute.Bool(key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(attributes, attribute.String(key, attr.Value.Duration().String()))
+	case slog.KindTime:
+		return append(
+			attributes,
+			attribute.String(key, attr.Value.Time().Format(time.RFC3339Nano)),
+		)
+	default:
+		return append(attributes, attribute.String(key, formatAnyValue(attr.Value.Any())))
+	}
+}
+
+// Formats values of kind [slog.KindAny] as JSON, to match how they're formatted by
+// [slog.JSONHandler] (and devlog). Falls back to [fmt.Sprint] for values that can't be encoded as
+// JSON.
+func formatAnyValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case error:
+		return value.Error()
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+func addAttrIfMissing(record *slog.Record, attr slog.Attr) {
+	for existingAttr := range record.Attrs {
+		if existingAttr.Key == attr.Key {
+			return
+		}
+	}
+	record.AddAttrs(attr)
+}
+
+// Should be the same keys as in devlog/handler.go (we don't import this across packages, as that
+// would require a dependency between them, whereas they're currently independent from each other).
+const (
+	traceIDAttrKey = "trace_id"
+	spanIDAttrKey  = "span_id"
+)
+
+const severityEventAttrKey = "log.severity"
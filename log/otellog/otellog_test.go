@@ -0,0 +1,224 @@
+package otellog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/otellog"
+)
+
+func TestTraceAndSpanIDs(t *testing.T) {
+	logger, output := setupLogger(nil, nil)
+
+	ctx, _ := startTestSpan(context.Background())
+	logger.Info(ctx, "Test", "key", "value")
+
+	logLine := parseLog(t, output)
+	assertField(t, logLine, "key", "value")
+	assertField(t, logLine, "trace_id", testTraceID.String())
+	assertField(t, logLine, "span_id", testSpanID.String())
+}
+
+func TestNoSpanInContext(t *testing.T) {
+	logger, output := setupLogger(nil, nil)
+
+	logger.Info(context.Background(), "Test")
+
+	logLine := parseLog(t, output)
+	for _, key := range []string{"trace_id", "span_id"} {
+		if _, ok := logLine[key]; ok {
+			t.Errorf("Expected no '%s' field when context has no span", key)
+		}
+	}
+}
+
+func TestSpanEvents(t *testing.T) {
+	logger, _ := setupLogger(nil, nil)
+
+	ctx, span := startTestSpan(context.Background())
+	ctx = log.AddContextAttrs(ctx, "ctxKey", "ctxValue")
+
+	logger.
+		With("handlerKey", "handlerValue").
+		WithGroup("group").
+		Error(ctx, errors.New("something went wrong"), "Request failed", "key", 1)
+	logger.Info(ctx, "Not recorded as span event")
+
+	if len(span.events) != 1 {
+		t.Fatalf("Expected 1 span event, got %d: %+v", len(span.events), span.events)
+	}
+
+	event := span.events[0]
+	if event.name != "Request failed" {
+		t.Errorf("Expected span event name 'Request failed', got '%s'", event.name)
+	}
+
+	expectedAttributes := []attribute.KeyValue{
+		attribute.String("log.severity", "ERROR"),
+		attribute.String("handlerKey", "handlerValue"),
+		attribute.String("group.cause", "something went wrong"),
+		attribute.Int64("group.key", 1),
+		attribute.String("group.ctxKey", "ctxValue"),
+	}
+	if !reflect.DeepEqual(event.attributes, expectedAttributes) {
+		t.Errorf(
+			"Unexpected span event attributes\nWant: %v\n Got: %v",
+			expectedAttributes,
+			event.attributes,
+		)
+	}
+}
+
+func TestSpanEventLevel(t *testing.T) {
+	// Wrapped handler only logs errors, but we want to record DEBUG logs as span events
+	logger, output := setupLogger(
+		&otellog.Options{SpanEventLevel: slog.LevelDebug},
+		&slog.HandlerOptions{Level: slog.LevelError},
+	)
+
+	ctx, span := startTestSpan(context.Background())
+
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Expected DEBUG level to be enabled when context has a recording span")
+	}
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("Expected DEBUG level to be disabled when context has no span")
+	}
+
+	logger.Debug(ctx, "Debug log")
+
+	if len(span.events) != 1 || span.events[0].name != "Debug log" {
+		t.Errorf("Expected DEBUG log to be recorded as span event, got %+v", span.events)
+	}
+	if output.Len() != 0 {
+		t.Errorf("Expected DEBUG log to not be written by wrapped handler, got: %s", output)
+	}
+}
+
+func TestDisableSpanEvents(t *testing.T) {
+	logger, output := setupLogger(&otellog.Options{DisableSpanEvents: true}, nil)
+
+	ctx, span := startTestSpan(context.Background())
+	logger.ErrorMessage(ctx, "Test")
+
+	if len(span.events) != 0 {
+		t.Errorf("Expected no span events, got %+v", span.events)
+	}
+	// Trace and span IDs should still be added
+	assertField(t, parseLog(t, output), "trace_id", testTraceID.String())
+}
+
+func TestNilHandler(t *testing.T) {
+	var panicValue any
+
+	passNilToHandler := func() {
+		defer func() {
+			panicValue = recover()
+		}()
+
+		otellog.Handler(nil, nil)
+	}
+	passNilToHandler()
+
+	expectedPanicValue := "nil slog.Handler given to otellog.Handler"
+	if panicValue != expectedPanicValue {
+		t.Errorf(
+			`Unexpected panic value
+Want: %v
+ Got: %v`,
+			expectedPanicValue,
+			panicValue,
+		)
+	}
+}
+
+var (
+	testTraceID = trace.TraceID{
+		0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6,
+		0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36,
+	}
+	testSpanID = trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+)
+
+// We don't want to depend on the OpenTelemetry SDK just for tests, so we implement a minimal
+// recording span here.
+type testSpan struct {
+	noop.Span
+	events []testSpanEvent
+}
+
+type testSpanEvent struct {
+	name       string
+	attributes []attribute.KeyValue
+}
+
+func startTestSpan(ctx context.Context) (context.Context, *testSpan) {
+	span := &testSpan{Span: noop.Span{}, events: nil}
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (span *testSpan) IsRecording() bool {
+	return true
+}
+
+func (span *testSpan) SpanContext() trace.SpanContext {
+	return trace.NewSpanContext(
+		trace.SpanContextConfig{
+			TraceID:    testTraceID,
+			SpanID:     testSpanID,
+			TraceFlags: trace.FlagsSampled,
+			TraceState: trace.TraceState{},
+			Remote:     false,
+		},
+	)
+}
+
+func (span *testSpan) AddEvent(name string, options ...trace.EventOption) {
+	config := trace.NewEventConfig(options...)
+	span.events = append(
+		span.events,
+		testSpanEvent{name: name, attributes: config.Attributes()},
+	)
+}
+
+func setupLogger(
+	options *otellog.Options,
+	handlerOptions *slog.HandlerOptions,
+) (log.Logger, *bytes.Buffer) {
+	if handlerOptions == nil {
+		handlerOptions = &slog.HandlerOptions{Level: slog.LevelDebug}
+	}
+
+	var output bytes.Buffer
+	handler := otellog.Handler(slog.NewJSONHandler(&output, handlerOptions), options)
+	return log.New(handler), &output
+}
+
+func parseLog(t *testing.T, output *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	var logLine map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output.String())), &logLine); err != nil {
+		t.Fatalf("Failed to parse log output '%s': %v", output.String(), err)
+	}
+	return logLine
+}
+
+func assertField(t *testing.T, logLine map[string]any, key string, expected any) {
+	t.Helper()
+
+	if actual := logLine[key]; actual != expected {
+		t.Errorf("Expected log field '%s' to be '%v', got '%v'", key, expected, actual)
+	}
+}
@@ -0,0 +1,275 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// RecoverPanic recovers a panic in the current goroutine, and logs it at the ERROR log level with
+// the given message, along with any given log attributes. It uses the [slog.Default] logger.
+//
+// It must be called directly with defer, as that is the only way the [recover] built-in can stop a
+// panic:
+//
+//	func processEvent(ctx context.Context, event Event) {
+//		defer log.RecoverPanic(ctx, "Panic while processing event", "eventId", event.ID)
+//		// ...
+//	}
+//
+// The panic value is added as a 'cause' attribute (unwrapped like other errors, if the panic value
+// is an error), and the stack trace of the panicking goroutine is added as a 'stack' attribute.
+// The source of the log (if enabled by the log handler) is the function that panicked.
+//
+// If you want the panic to continue after logging it, use [log.RecoverAndRepanic] instead. If you
+// want to start a goroutine that logs its panics, use [log.Go].
+//
+// The context parameter is used to add context attributes from [log.AddContextAttrs]. If you're in
+// a function without a context parameter, you may pass a nil context. But ideally, you should pass
+// a context wherever you do logging, in order to propagate context attributes.
+//
+// # Log attributes
+//
+// A log attribute (abbreviated "attr") is a key-value pair attached to a log line. You can pass
+// attributes in the following ways:
+//
+//	// Pairs of string keys and corresponding values:
+//	defer log.RecoverPanic(ctx, "Message", "key1", "value1", "key2", 2)
+//	// slog.Attr objects:
+//	defer log.RecoverPanic(ctx, "Message", slog.String("key1", "value1"), slog.Int("key2", 2))
+//	// Or a mix of the two:
+//	defer log.RecoverPanic(ctx, "Message", "key1", "value1", slog.Int("key2", 2))
+//
+// When outputting logs as JSON (using e.g. [slog.JSONHandler]), these become fields in the logged
+// JSON object. This allows you to filter and query on the attributes in the log analysis tool of
+// your choice, in a more structured manner than if you were to just use string concatenation.
+func RecoverPanic(ctx context.Context, message string, logAttributes ...any) {
+	if panicValue := recover(); panicValue != nil {
+		Default().logPanic(ctx, panicValue, message, logAttributes)
+	}
+}
+
+// RecoverAndRepanic recovers a panic in the current goroutine, logs it in the same way as
+// [log.RecoverPanic], and then panics again with the same value. It uses the [slog.Default] logger.
+//
+// This is useful when you want to make sure that a panic is logged through your log handler
+// (including context attributes), but you still want the panic to crash the program or be handled
+// further up the stack. Like RecoverPanic, it must be called directly with defer:
+//
+//	defer log.RecoverAndRepanic(ctx, "Unexpected panic")
+//
+// See [log.RecoverPanic] for more on the log output and attributes.
+func RecoverAndRepanic(ctx context.Context, message string, logAttributes ...any) {
+	if panicValue := recover(); panicValue != nil {
+		Default().logPanic(ctx, panicValue, message, logAttributes)
+		panic(panicValue)
+	}
+}
+
+// Go runs the given function in a new goroutine, and recovers and logs any panic that occurs in
+// it (see [log.RecoverPanic]). It uses the [slog.Default] logger at the time Go is called.
+//
+// The given context is passed to the function, and used to add context attributes from
+// [log.AddContextAttrs] to the panic log. If the context is nil, [context.Background] is passed
+// instead.
+func Go(ctx context.Context, function func(ctx context.Context)) {
+	Default().Go(ctx, function)
+}
+
+// RecoverPanic recovers a panic in the current goroutine, and logs it at the ERROR log level with
+// the given message, along with any given log attributes.
+//
+// It must be called directly with defer, as that is the only way the [recover] built-in can stop a
+// panic:
+//
+//	defer logger.RecoverPanic(ctx, "Panic while processing event", "eventId", event.ID)
+//
+// See [log.RecoverPanic] for more on the log output and attributes.
+func (logger Logger) RecoverPanic(ctx context.Context, message string, logAttributes ...any) {
+	if panicValue := recover(); panicValue != nil {
+		logger.logPanic(ctx, panicValue, message, logAttributes)
+	}
+}
+
+// RecoverAndRepanic recovers a panic in the current goroutine, logs it in the same way as
+// [Logger.RecoverPanic], and then panics again with the same value.
+//
+// Like RecoverPanic, it must be called directly with defer:
+//
+//	defer logger.RecoverAndRepanic(ctx, "Unexpected panic")
+//
+// See [log.RecoverPanic] for more on the log output and attributes.
+func (logger Logger) RecoverAndRepanic(ctx context.Context, message string, logAttributes ...any) {
+	if panicValue := recover(); panicValue != nil {
+		logger.logPanic(ctx, panicValue, message, logAttributes)
+		panic(panicValue)
+	}
+}
+
+// Go runs the given function in a new goroutine, and recovers and logs any panic that occurs in
+// it (see [log.RecoverPanic]).
+//
+// The given context is passed to the function, and used to add context attributes from
+// [log.AddContextAttrs] to the panic log. If the context is nil, [context.Background] is passed
+// instead.
+func (logger Logger) Go(ctx context.Context, function func(ctx context.Context)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	go func() {
+		defer logger.RecoverPanic(ctx, "Goroutine panicked")
+		function(ctx)
+	}()
+}
+
+func (logger Logger) logPanic(
+	ctx context.Context,
+	panicValue any,
+	message string,
+	logAttributes []any,
+) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if !logger.Enabled(ctx, slog.LevelError) {
+		return
+	}
+
+	// Skips 3, because we want to skip:
+	// - the call to runtime.Callers
+	// - the call to logPanic (this function)
+	// - the call to the public function that recovered the panic
+	// We skip the rest of the frames up to the panic in newPanicError.
+	programCounters := make([]uintptr, 64)
+	for {
+		count := runtime.Callers(3, programCounters)
+		if count < len(programCounters) {
+			programCounters = programCounters[:count]
+			break
+		}
+		// Grows the buffer until it fits the whole stack, since panics are often caused by deep
+		// recursion, and we don't want to cut off the stack trace
+		programCounters = make([]uintptr, len(programCounters)*2)
+	}
+	err := newPanicError(panicValue, programCounters)
+
+	logger.logWithSource(
+		ctx,
+		slog.LevelError,
+		err.programCounter,
+		message,
+		nil,
+		parseAttrs(nil, logAttributes),
+		err,
+		nil,
+	)
+}
+
+// panicError is the error that we log for a recovered panic. It implements hasLogAttributes, to
+// add the stack trace of the panic as a log attribute.
+type panicError struct {
+	value any
+	stack []string
+	// Program counter of the function that panicked, for the log record's source.
+	programCounter uintptr
+}
+
+// Takes program counters from a call to [runtime.Callers] in a deferred function that recovered
+// from a panic, and uses them to construct the stack trace of the panic.
+func newPanicError(panicValue any, programCounters []uintptr) *panicError {
+	// When a deferred function is called by a panic, the call stack looks like this:
+	// - the deferred function
+	// - runtime.gopanic
+	// - other runtime functions (e.g. runtime.panicmem for a nil pointer dereference)
+	// - the function that panicked
+	// So we skip frames until we're past the runtime functions after runtime.gopanic.
+	panicStart := 0
+	passedGopanic := false
+	for i, programCounter := range programCounters {
+		function := getFunctionName(programCounter)
+		if !passedGopanic {
+			if function == "runtime.gopanic" {
+				passedGopanic = true
+			}
+			continue
+		}
+		if !strings.HasPrefix(function, "runtime.") {
+			panicStart = i
+			break
+		}
+	}
+	programCounters = programCounters[panicStart:]
+
+	err := &panicError{value: panicValue, stack: nil, programCounter: 0}
+	if len(programCounters) != 0 {
+		err.programCounter = programCounters[0]
+	}
+
+	frames := runtime.CallersFrames(programCounters)
+	for {
+		frame, more := frames.Next()
+		// Skip functions from the Go runtime at the bottom of the stack (runtime.main and
+		// runtime.goexit), as they just add noise
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			err.stack = append(err.stack, formatStackFrame(frame))
+		}
+		if !more {
+			break
+		}
+	}
+
+	return err
+}
+
+func (err *panicError) Error() string {
+	return "panic: " + fmt.Sprint(err.value)
+}
+
+// If the panic value is an error, we unwrap it, so that it's formatted like other wrapped errors in
+// the 'cause' attribute (our error message follows the "panic: %w" pattern that we split on).
+func (err *panicError) Unwrap() error {
+	if wrapped, isError := err.value.(error); isError {
+		return wrapped
+	}
+	return nil
+}
+
+// LogAttrs implements hasLogAttributes, to add the stack trace to the log.
+func (err *panicError) LogAttrs() []slog.Attr {
+	if len(err.stack) == 0 {
+		return nil
+	}
+	return []slog.Attr{slog.Any(stackTraceAttrKey, err.stack)}
+}
+
+// Formats a stack frame as "function (file:line)", which is the same format that the devlog handler
+// uses for the source attribute.
+func formatStackFrame(frame runtime.Frame) string {
+	var builder strings.Builder
+	builder.WriteString(frame.Function)
+	if frame.File != "" {
+		builder.WriteString(" (")
+		builder.WriteString(frame.File)
+		if frame.Line != 0 {
+			builder.WriteByte(':')
+			builder.WriteString(strconv.Itoa(frame.Line))
+		}
+		builder.WriteByte(')')
+	}
+	return builder.String()
+}
+
+func getFunctionName(programCounter uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{programCounter})
+	frame, _ := frames.Next()
+	return frame.Function
+}
+
+// Should be the same key as in devlog/handler.go (we don't import this across packages, as that
+// would require a dependency between them, whereas they're currently independent from each other).
+const stackTraceAttrKey = "stack"
@@ -0,0 +1,170 @@
+package log_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	ctx := log.AddContextAttrs(ctx, "contextKey", "contextValue")
+
+	output := getLogOutput(
+		func() {
+			defer log.RecoverPanic(ctx, "Recovered panic", "key", "value")
+			panicWithValue("something went wrong")
+		},
+	)
+
+	logEntry := parsePanicLog(t, output)
+	assertPanicLog(t, logEntry, "Recovered panic", "panic: something went wrong")
+	if logEntry["key"] != "value" {
+		t.Errorf("Expected log attribute 'key' in panic log, got: %s", output)
+	}
+	if logEntry["contextKey"] != "contextValue" {
+		t.Errorf("Expected context attribute 'contextKey' in panic log, got: %s", output)
+	}
+}
+
+func TestRecoverPanicWithError(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			defer log.RecoverPanic(ctx, "Recovered panic")
+			panicWithValue(errors.New("the error"))
+		},
+	)
+
+	logEntry := parsePanicLog(t, output)
+	assertPanicLog(t, logEntry, "Recovered panic", []any{"panic", "the error"})
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	var repanicValue any
+
+	output := getLogOutput(
+		func() {
+			defer func() {
+				repanicValue = recover()
+			}()
+			defer log.RecoverAndRepanic(ctx, "Panic")
+			panicWithValue("something went wrong")
+		},
+	)
+
+	if repanicValue != "something went wrong" {
+		t.Errorf("Expected RecoverAndRepanic to panic again with same value, got: %v", repanicValue)
+	}
+
+	logEntry := parsePanicLog(t, output)
+	assertPanicLog(t, logEntry, "Panic", "panic: something went wrong")
+}
+
+func TestGo(t *testing.T) {
+	output := make(chan string)
+	logger := log.New(slog.NewJSONHandler(channelWriter(output), nil))
+
+	logger.Go(
+		log.AddContextAttrs(ctx, "contextKey", "contextValue"),
+		func(ctx context.Context) {
+			panicWithValue("goroutine failed")
+		},
+	)
+
+	logEntry := parsePanicLog(t, <-output)
+	assertPanicLog(t, logEntry, "Goroutine panicked", "panic: goroutine failed")
+	if logEntry["contextKey"] != "contextValue" {
+		t.Errorf("Expected context attribute 'contextKey' in panic log, got: %v", logEntry)
+	}
+}
+
+func TestPanicLogSource(t *testing.T) {
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{AddSource: true},
+		func() {
+			defer log.RecoverPanic(ctx, "Recovered panic")
+			panicWithValue("something went wrong")
+		},
+	)
+
+	// Source should be the function that panicked, not where the panic was recovered
+	assertContains(t, output, `"function":"hermannm.dev/devlog/log_test.panicWithValue"`)
+}
+
+func TestPanicWithDeepStack(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			defer log.RecoverPanic(ctx, "Recovered panic")
+			panicAtDepth(200)
+		},
+	)
+
+	logEntry := parsePanicLog(t, output)
+	stack, _ := logEntry["stack"].([]any)
+	// The stack trace should not be cut off, so it should include all the recursive calls
+	if len(stack) < 200 {
+		t.Errorf("Expected at least 200 frames in stack trace, got %d", len(stack))
+	}
+}
+
+//go:noinline
+func panicAtDepth(depth int) {
+	if depth == 0 {
+		panic("reached max depth")
+	}
+	panicAtDepth(depth - 1)
+}
+
+//go:noinline
+func panicWithValue(value any) {
+	panic(value)
+}
+
+func parsePanicLog(t *testing.T, output string) map[string]any {
+	t.Helper()
+
+	t.Log(strings.TrimSuffix(output, "\n"))
+
+	var logEntry map[string]any
+	if err := json.Unmarshal([]byte(output), &logEntry); err != nil {
+		t.Fatalf("Failed to parse log output: %v", err)
+	}
+	return logEntry
+}
+
+func assertPanicLog(t *testing.T, logEntry map[string]any, message string, cause any) {
+	t.Helper()
+
+	if logEntry["level"] != "ERROR" {
+		t.Errorf("Expected panic log at ERROR level, got: %v", logEntry["level"])
+	}
+	if logEntry["msg"] != message {
+		t.Errorf("Expected panic log message '%s', got: %v", message, logEntry["msg"])
+	}
+	if !reflect.DeepEqual(logEntry["cause"], cause) {
+		t.Errorf("Expected panic log cause '%v', got: %v", cause, logEntry["cause"])
+	}
+
+	stack, ok := logEntry["stack"].([]any)
+	if !ok || len(stack) == 0 {
+		t.Fatalf("Expected stack trace in panic log, got: %v", logEntry["stack"])
+	}
+	firstFrame, _ := stack[0].(string)
+	if !strings.HasPrefix(firstFrame, "hermannm.dev/devlog/log_test.panicWithValue (") ||
+		!strings.Contains(firstFrame, "panic_test.go:") {
+		t.Errorf("Expected stack trace to start at the panicking function, got: %v", stack)
+	}
+}
+
+// Sends each write on the given channel, so we can wait for logs from other goroutines.
+type channelWriter chan<- string
+
+func (writer channelWriter) Write(bytes []byte) (int, error) {
+	writer <- string(bytes)
+	return len(bytes), nil
+}
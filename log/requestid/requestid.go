@@ -0,0 +1,69 @@
+// Package requestid provides the request IDs used by the [hermannm.dev/devlog/log/httplog]
+// middleware and the [hermannm.dev/devlog/log/grpclog] interceptors. They share request IDs through
+// the context, so that a request ID from an incoming HTTP request is passed on by gRPC clients, and
+// validate incoming request IDs in the same way.
+//
+// You can use this package to get the request ID of the current request in your own code (with
+// [FromContext]), or to set a request ID for contexts that don't come from the HTTP middleware or
+// gRPC interceptors (with [NewContext]).
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	mathrand "math/rand/v2"
+)
+
+// New generates a random request ID, as a 128-bit hex string.
+func New() string {
+	var bytes [16]byte
+	if _, err := rand.Read(bytes[:]); err != nil {
+		// Before Go 1.24, crypto/rand.Read may return an error if the operating system's random
+		// source fails. Request IDs don't need to be cryptographically secure, so we fall back to
+		// math/rand instead of returning an all-zero ID.
+		binary.BigEndian.PutUint64(bytes[:8], mathrand.Uint64())
+		binary.BigEndian.PutUint64(bytes[8:], mathrand.Uint64())
+	}
+	return hex.EncodeToString(bytes[:])
+}
+
+// IsValid checks that the given request ID from an incoming request is not blank, has a reasonable
+// length (at most 128 bytes), and only contains printable ASCII characters. Request IDs from
+// incoming requests should be checked with this before use, since we don't want to trust arbitrary
+// header values from clients, as they end up in our logs.
+func IsValid(requestID string) bool {
+	if requestID == "" || len(requestID) > maxLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+const maxLength = 128
+
+// NewContext returns a copy of the given parent context with the given request ID. The gRPC client
+// interceptors in grpclog pass the request ID on to the server.
+func NewContext(parent context.Context, requestID string) context.Context {
+	return context.WithValue(parent, contextKey, requestID)
+}
+
+// FromContext returns the request ID from [NewContext] in the given context (or one of its
+// parents), or a blank string if there is none.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(contextKey).(string)
+	return requestID
+}
+
+// Use struct{} to avoid allocations, as recommended by [context.WithValue].
+type contextKeyType struct{}
+
+var contextKey = contextKeyType{}
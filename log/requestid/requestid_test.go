@@ -0,0 +1,45 @@
+package requestid_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"hermannm.dev/devlog/log/requestid"
+)
+
+func TestIsValid(t *testing.T) {
+	testCases := []struct {
+		requestID string
+		expected  bool
+	}{
+		{"test-request-id", true},
+		{requestid.New(), true},
+		{"", false},
+		{"invalid\nrequest-id", false},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+	}
+
+	for _, testCase := range testCases {
+		if valid := requestid.IsValid(testCase.requestID); valid != testCase.expected {
+			t.Errorf(
+				"Expected IsValid(%q) to be %v, got %v",
+				testCase.requestID,
+				testCase.expected,
+				valid,
+			)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := requestid.NewContext(context.Background(), "test-request-id")
+	if requestID := requestid.FromContext(ctx); requestID != "test-request-id" {
+		t.Errorf("Expected request ID 'test-request-id' in context, got '%s'", requestID)
+	}
+
+	if requestID := requestid.FromContext(nil); requestID != "" {
+		t.Errorf("Expected blank request ID for nil context, got '%s'", requestID)
+	}
+}
@@ -0,0 +1,48 @@
+package log
+
+import (
+	"log/slog"
+)
+
+// Table returns a log attribute for a list of structs or maps, which the devlog handler shows as an
+// aligned table, with a column for each JSON field:
+//
+//	log.Info(ctx, "Synced users", log.Table("users", users))
+//
+// Gives the following output with devlog:
+//
+//	users:
+//	  id  name   email
+//	  1   Alice  alice@example.com
+//	  2   Bob    bob@example.com
+//
+// The devlog handler can also show slices of structs or maps as tables without this, by enabling
+// its RenderTables option. Table is for when you want a table for a specific attribute only. Other
+// log handlers get the rows as a plain attribute value, so JSON handlers output them as an array.
+// If the rows are not a list of JSON objects, devlog falls back to showing them as JSON.
+func Table(key string, rows any) slog.Attr {
+	return slog.Any(key, tableValue{rows: rows})
+}
+
+type tableValue struct {
+	rows any
+}
+
+// LogValue implements [slog.LogValuer], resolving the table to its rows.
+func (table tableValue) LogValue() slog.Value {
+	return slog.AnyValue(table.rows)
+}
+
+// Kept as-is in context attributes, so that the devlog handler can recognize it.
+func (tableValue) noCache() {}
+
+// Used by BufferHandler to keep the rows as they were when the log was made.
+func (table tableValue) snapshot() slog.LogValuer {
+	return tableValue{rows: snapshotJSON(table.rows)}
+}
+
+// TableRows returns the rows of the table. The devlog handler checks for this method, to show the
+// rows as a table.
+func (table tableValue) TableRows() any {
+	return table.rows
+}
@@ -0,0 +1,38 @@
+package log_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+type tableRow struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTable(t *testing.T) {
+	rows := []tableRow{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+
+	output := getLogOutput(
+		func() {
+			log.Info(ctx, "Test", log.Table("users", rows))
+		},
+	)
+
+	verifyLogAttrs(t, output, `"users":[{"id":1,"name":"Alice"},{"id":2,"name":"Bob"}]`)
+}
+
+func TestTableInContextAttrs(t *testing.T) {
+	ctx := log.AddContextAttrs(ctx, log.Table("users", []tableRow{{ID: 1, Name: "Alice"}}))
+
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{Level: slog.LevelInfo},
+		func() {
+			log.Info(ctx, "Test")
+		},
+	)
+
+	verifyLogAttrs(t, output, `"users":[{"id":1,"name":"Alice"}]`)
+}
@@ -0,0 +1,226 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+
+	"hermannm.dev/devlog/internal/messagetemplate"
+)
+
+// Errort logs a message created from the given message template at the ERROR log level, and adds a
+// 'cause' attribute with the given error. It uses the [slog.Default] logger.
+//
+// See [log.Infot] for how message templates work.
+func Errort(ctx context.Context, err error, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, slog.LevelError, messageTemplate, logAttributes, err)
+}
+
+// ErrorMessaget logs a message created from the given message template at the ERROR log level,
+// without an error. It uses the [slog.Default] logger.
+//
+// See [log.Infot] for how message templates work.
+func ErrorMessaget(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, slog.LevelError, messageTemplate, logAttributes, nil)
+}
+
+// Warnt logs a message created from the given message template at the WARN log level. It uses the
+// [slog.Default] logger.
+//
+// See [log.Infot] for how message templates work.
+func Warnt(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, slog.LevelWarn, messageTemplate, logAttributes, nil)
+}
+
+// Infot logs a message created from the given message template at the INFO log level. It uses the
+// [slog.Default] logger.
+//
+// A message template is a log message with placeholders on the form {key}, which are replaced by
+// the values of the log attributes with the same keys:
+//
+//	log.Infot(ctx, "User {userId} logged in from {ip}", "userId", 1234, "ip", "192.0.2.1")
+//	// Message: "User 1234 logged in from 192.0.2.1"
+//
+// Unlike [log.Infof], the values are also added as log attributes, so you get both a readable
+// message and structured data to filter and query on. The template itself is added as a
+// 'messageTemplate' attribute, which lets you group logs by template in your log analysis tool
+// (since the message varies with the values). The devlog handler uses it to highlight substituted
+// values in the message, and omits it from the attribute list.
+//
+// Placeholders without a matching attribute are left as-is. To write literal braces, double them:
+// "{{" and "}}" become "{" and "}".
+//
+// The context parameter is used to add context attributes from [log.AddContextAttrs]. If you're in
+// a function without a context parameter, you may pass a nil context. But ideally, you should pass
+// a context wherever you do logging, in order to propagate context attributes.
+func Infot(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, slog.LevelInfo, messageTemplate, logAttributes, nil)
+}
+
+// Debugt logs a message created from the given message template at the DEBUG log level. It uses
+// the [slog.Default] logger.
+//
+// See [log.Infot] for how message templates work.
+func Debugt(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, slog.LevelDebug, messageTemplate, logAttributes, nil)
+}
+
+// Logt logs a message created from the given message template at the given log level. It uses the
+// [slog.Default] logger.
+//
+// This function lets you set the log level dynamically. If you just want to log at a specific
+// level, you should use a more specific log function ([log.Infot], [log.Warnt], etc.) instead.
+//
+// See [log.Infot] for how message templates work.
+func Logt(ctx context.Context, level slog.Level, messageTemplate string, logAttributes ...any) {
+	Default().logTemplate(ctx, level, messageTemplate, logAttributes, nil)
+}
+
+// Errort logs a message created from the given message template at the ERROR log level, and adds a
+// 'cause' attribute with the given error.
+//
+// See [Logger.Infot] for how message templates work.
+func (logger Logger) Errort(
+	ctx context.Context,
+	err error,
+	messageTemplate string,
+	logAttributes ...any,
+) {
+	logger.logTemplate(ctx, slog.LevelError, messageTemplate, logAttributes, err)
+}
+
+// ErrorMessaget logs a message created from the given message template at the ERROR log level,
+// without an error.
+//
+// See [Logger.Infot] for how message templates work.
+func (logger Logger) ErrorMessaget(
+	ctx context.Context,
+	messageTemplate string,
+	logAttributes ...any,
+) {
+	logger.logTemplate(ctx, slog.LevelError, messageTemplate, logAttributes, nil)
+}
+
+// Warnt logs a message created from the given message template at the WARN log level.
+//
+// See [Logger.Infot] for how message templates work.
+func (logger Logger) Warnt(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	logger.logTemplate(ctx, slog.LevelWarn, messageTemplate, logAttributes, nil)
+}
+
+// Infot logs a message created from the given message template at the INFO log level.
+//
+// A message template is a log message with placeholders on the form {key}, which are replaced by
+// the values of the log attributes with the same keys:
+//
+//	logger.Infot(ctx, "User {userId} logged in from {ip}", "userId", 1234, "ip", "192.0.2.1")
+//	// Message: "User 1234 logged in from 192.0.2.1"
+//
+// Unlike [Logger.Infof], the values are also added as log attributes, so you get both a readable
+// message and structured data to filter and query on. The template itself is added as a
+// 'messageTemplate' attribute, which lets you group logs by template in your log analysis tool
+// (since the message varies with the values). The devlog handler uses it to highlight substituted
+// values in the message, and omits it from the attribute list.
+//
+// Placeholders without a matching attribute are left as-is. To write literal braces, double them:
+// "{{" and "}}" become "{" and "}".
+//
+// The context parameter is used to add context attributes from [log.AddContextAttrs]. If you're in
+// a function without a context parameter, you may pass a nil context. But ideally, you should pass
+// a context wherever you do logging, in order to propagate context attributes.
+func (logger Logger) Infot(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	logger.logTemplate(ctx, slog.LevelInfo, messageTemplate, logAttributes, nil)
+}
+
+// Debugt logs a message created from the given message template at the DEBUG log level.
+//
+// See [Logger.Infot] for how message templates work.
+func (logger Logger) Debugt(ctx context.Context, messageTemplate string, logAttributes ...any) {
+	logger.logTemplate(ctx, slog.LevelDebug, messageTemplate, logAttributes, nil)
+}
+
+// Logt logs a message created from the given message template at the given log level.
+//
+// This function lets you set the log level dynamically. If you just want to log at a specific
+// level, you should use a more specific log function ([Logger.Infot], [Logger.Warnt], etc.)
+// instead.
+//
+// See [Logger.Infot] for how message templates work.
+func (logger Logger) Logt(
+	ctx context.Context,
+	level slog.Level,
+	messageTemplate string,
+	logAttributes ...any,
+) {
+	logger.logTemplate(ctx, level, messageTemplate, logAttributes, nil)
+}
+
+func (logger Logger) logTemplate(
+	ctx context.Context,
+	level slog.Level,
+	messageTemplate string,
+	logAttributes []any,
+	err error,
+) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	// Skips 3, because we want to skip:
+	// - the call to runtime.Callers
+	// - the call to logTemplate (this function)
+	// - the call to the public log function that uses this function
+	var programCounters [1]uintptr
+	runtime.Callers(3, programCounters[:])
+
+	attrs := parseAttrs(nil, logAttributes)
+	message := expandMessageTemplate(messageTemplate, attrs)
+	attrs = appendAttr(attrs, slog.String(messageTemplateAttrKey, messageTemplate))
+
+	logger.logWithSource(ctx, level, programCounters[0], message, nil, attrs, err, nil)
+}
+
+// Replaces placeholders on the form {key} in the given template with the values of the attributes
+// with the same keys. Placeholders without a matching attribute are left as-is, and "{{" and "}}"
+// are unescaped to "{" and "}".
+func expandMessageTemplate(template string, attrs []slog.Attr) string {
+	// Fast path for templates without placeholders or escaped braces
+	if !strings.ContainsAny(template, "{}") {
+		return template
+	}
+
+	var message strings.Builder
+	message.Grow(len(template))
+
+	messagetemplate.Parse(
+		template,
+		func(part messagetemplate.Part) {
+			if part.Key != "" {
+				if value, ok := findAttrValue(attrs, part.Key); ok {
+					message.WriteString(value.String())
+					return
+				}
+			}
+			message.WriteString(part.Text)
+		},
+	)
+
+	return message.String()
+}
+
+func findAttrValue(attrs []slog.Attr, key string) (slog.Value, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value.Resolve(), true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Should be the same key as in devlog's handler.go.
+const messageTemplateAttrKey = "messageTemplate"
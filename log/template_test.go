@@ -0,0 +1,121 @@
+package log_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"hermannm.dev/devlog/log"
+)
+
+func TestMessageTemplate(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			log.Infot(ctx, "User {userId} logged in from {ip}", "userId", 1234, "ip", "192.0.2.1")
+		},
+	)
+
+	verifyLogOutput(
+		t,
+		output,
+		"INFO",
+		"User 1234 logged in from 192.0.2.1",
+		`"userId":1234,"ip":"192.0.2.1","messageTemplate":"User {userId} logged in from {ip}"`,
+	)
+}
+
+func TestMessageTemplateFunctions(t *testing.T) {
+	err := errors.New("something went wrong")
+	logger, output := setupLogger()
+
+	for _, testCase := range []struct {
+		expectedLevel string
+		logFunc       func(messageTemplate string, logAttributes ...any)
+	}{
+		{"ERROR", func(template string, attrs ...any) { log.Errort(ctx, err, template, attrs...) }},
+		{"ERROR", func(template string, attrs ...any) { log.ErrorMessaget(ctx, template, attrs...) }},
+		{"WARN", func(template string, attrs ...any) { log.Warnt(ctx, template, attrs...) }},
+		{"INFO", func(template string, attrs ...any) { log.Infot(ctx, template, attrs...) }},
+		{"DEBUG", func(template string, attrs ...any) { log.Debugt(ctx, template, attrs...) }},
+		{
+			"WARN",
+			func(template string, attrs ...any) {
+				log.Logt(ctx, slog.LevelWarn, template, attrs...)
+			},
+		},
+		{
+			"ERROR",
+			func(template string, attrs ...any) { logger.Errort(ctx, err, template, attrs...) },
+		},
+		{
+			"ERROR",
+			func(template string, attrs ...any) { logger.ErrorMessaget(ctx, template, attrs...) },
+		},
+		{"WARN", func(template string, attrs ...any) { logger.Warnt(ctx, template, attrs...) }},
+		{"INFO", func(template string, attrs ...any) { logger.Infot(ctx, template, attrs...) }},
+		{"DEBUG", func(template string, attrs ...any) { logger.Debugt(ctx, template, attrs...) }},
+		{
+			"INFO",
+			func(template string, attrs ...any) {
+				logger.Logt(ctx, slog.LevelInfo, template, attrs...)
+			},
+		},
+	} {
+		output.Reset()
+		testCase.logFunc("Test {key}", "key", "value")
+
+		level, message, _ := parseLogOutput(t, output.String())
+		if level != testCase.expectedLevel {
+			unexpectedLogOutput(t, "log level", level, testCase.expectedLevel)
+		}
+		if message != "Test value" {
+			unexpectedLogOutput(t, "log message", message, "Test value")
+		}
+		assertContains(t, output.String(), `"key":"value"`, `"messageTemplate":"Test {key}"`)
+	}
+}
+
+func TestMessageTemplateEscapesAndUnmatchedPlaceholders(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			log.Infot(
+				ctx,
+				"{{literal}} {missing} {key} {} { {nested{key}} }}",
+				slog.String("key", "value"),
+			)
+		},
+	)
+
+	_, message, _ := parseLogOutput(t, output)
+	expected := "{literal} {missing} value {} { {nestedvalue} }"
+	if message != expected {
+		unexpectedLogOutput(t, "log message", message, expected)
+	}
+}
+
+func TestMessageTemplateWithLogValuer(t *testing.T) {
+	output := getLogOutput(
+		func() {
+			log.Infot(ctx, "Logged in as {user}", "user", log.Lazy(func() any { return "alice" }))
+		},
+	)
+
+	verifyLogOutput(
+		t,
+		output,
+		"INFO",
+		"Logged in as alice",
+		`"user":"alice","messageTemplate":"Logged in as {user}"`,
+	)
+}
+
+func TestMessageTemplateSource(t *testing.T) {
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{AddSource: true},
+		func() {
+			log.Infot(ctx, "Test {key}", "key", "value")
+		},
+	)
+
+	assertContains(t, output, "TestMessageTemplateSource", "template_test.go")
+}
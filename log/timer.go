@@ -0,0 +1,203 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// TimerOptions configure the timer returned by [log.Observe].
+type TimerOptions struct {
+	// Level is the level to log at when the duration is below all the thresholds in
+	// SlowThresholds.
+	// If nil, defaults to [slog.LevelInfo].
+	Level slog.Leveler
+
+	// MinDuration is the minimum duration to log. If the measured duration is shorter than this,
+	// nothing is logged. This lets you only log operations that are slower than expected.
+	// If 0, all durations are logged.
+	MinDuration time.Duration
+
+	// SlowThresholds lets you escalate the log level for slow operations. If the measured duration
+	// is at or above one or more of the thresholds, we log at the level of the highest of those
+	// thresholds. For example, to log at WARN above 1 second, and at ERROR above 5 seconds:
+	//
+	//	SlowThresholds: []log.SlowThreshold{
+	//		{Duration: 1 * time.Second, Level: slog.LevelWarn},
+	//		{Duration: 5 * time.Second, Level: slog.LevelError},
+	//	}
+	SlowThresholds []SlowThreshold
+}
+
+// SlowThreshold is a duration at or above which [log.Observe] logs at the given level (see
+// [TimerOptions.SlowThresholds]).
+type SlowThreshold struct {
+	Duration time.Duration
+	Level    slog.Level
+}
+
+// Timer starts measuring the duration of an operation, and returns a function that stops the timer
+// and logs the given message at the INFO log level, with the measured duration in a 'duration'
+// attribute. It uses the [slog.Default] logger.
+//
+// The returned function is typically deferred:
+//
+//	func loadConfig(ctx context.Context) {
+//		defer log.Timer(ctx, "Loaded config", "file", configFile)()
+//		// ...
+//	}
+//
+// The log gets the source location of the call to Timer, and the context attributes of the given
+// context. Only the first call to the returned function logs, so it's safe to call it more than
+// once. If the given attributes include a 'duration' key, it's replaced by the measured duration.
+// If you want to only log slow operations, or log at a higher level when an operation is slow,
+// use [log.Observe].
+func Timer(ctx context.Context, message string, logAttributes ...any) (stop func()) {
+	return Default().startTimer(ctx, message, nil, logAttributes)
+}
+
+// Observe is like [log.Timer], but lets you configure when and at which level to log the measured
+// duration. It uses the [slog.Default] logger.
+//
+// Example of logging a database query only if it takes more than 100 milliseconds, and logging at
+// the WARN level if it takes more than 1 second:
+//
+//	defer log.Observe(
+//		ctx,
+//		"Slow database query",
+//		&log.TimerOptions{
+//			MinDuration:    100 * time.Millisecond,
+//			SlowThresholds: []log.SlowThreshold{{Duration: time.Second, Level: slog.LevelWarn}},
+//		},
+//		"query", queryName,
+//	)()
+//
+// If options is nil, the default options are used (see [TimerOptions]), which makes this equivalent
+// to [log.Timer].
+func Observe(
+	ctx context.Context,
+	message string,
+	options *TimerOptions,
+	logAttributes ...any,
+) (stop func()) {
+	return Default().startTimer(ctx, message, options, logAttributes)
+}
+
+// Timer starts measuring the duration of an operation, and returns a function that stops the timer
+// and logs the given message at the INFO log level, with the measured duration in a 'duration'
+// attribute.
+//
+// The returned function is typically deferred:
+//
+//	func loadConfig(ctx context.Context) {
+//		defer logger.Timer(ctx, "Loaded config", "file", configFile)()
+//		// ...
+//	}
+//
+// The log gets the source location of the call to Timer, and the context attributes of the given
+// context. Only the first call to the returned function logs, so it's safe to call it more than
+// once. If the given attributes include a 'duration' key, it's replaced by the measured duration.
+// If you want to only log slow operations, or log at a higher level when an operation is slow,
+// use [Logger.Observe].
+//
+// The duration is measured with the logger's clock (see [Logger.WithClock]).
+func (logger Logger) Timer(
+	ctx context.Context,
+	message string,
+	logAttributes ...any,
+) (stop func()) {
+	return logger.startTimer(ctx, message, nil, logAttributes)
+}
+
+// Observe is like [Logger.Timer], but lets you configure when and at which level to log the
+// measured duration.
+//
+// Example of logging a database query only if it takes more than 100 milliseconds, and logging at
+// the WARN level if it takes more than 1 second:
+//
+//	defer logger.Observe(
+//		ctx,
+//		"Slow database query",
+//		&log.TimerOptions{
+//			MinDuration:    100 * time.Millisecond,
+//			SlowThresholds: []log.SlowThreshold{{Duration: time.Second, Level: slog.LevelWarn}},
+//		},
+//		"query", queryName,
+//	)()
+//
+// If options is nil, the default options are used (see [TimerOptions]), which makes this equivalent
+// to [Logger.Timer].
+func (logger Logger) Observe(
+	ctx context.Context,
+	message string,
+	options *TimerOptions,
+	logAttributes ...any,
+) (stop func()) {
+	return logger.startTimer(ctx, message, options, logAttributes)
+}
+
+func (logger Logger) startTimer(
+	ctx context.Context,
+	message string,
+	options *TimerOptions,
+	logAttributes []any,
+) (stop func()) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Skips 3, because we want to skip:
+	// - the call to runtime.Callers
+	// - the call to startTimer (this function)
+	// - the call to the public timer function that uses this function
+	var programCounters [1]uintptr
+	runtime.Callers(3, programCounters[:])
+
+	var level slog.Leveler = slog.LevelInfo
+	var minDuration time.Duration
+	var slowThresholds []SlowThreshold
+	if options != nil {
+		if options.Level != nil {
+			level = options.Level
+		}
+		minDuration = options.MinDuration
+		slowThresholds = options.SlowThresholds
+	}
+
+	start := logger.now()
+
+	// Uses sync.OnceFunc, so that only the first call to stop logs the duration, even if it's called
+	// again (for example, both explicitly and in a defer)
+	return sync.OnceFunc(func() {
+		duration := logger.now().Sub(start)
+		if duration < minDuration {
+			return
+		}
+
+		logLevel := level.Level()
+		var highestThreshold time.Duration
+		for _, threshold := range slowThresholds {
+			if duration >= threshold.Duration && threshold.Duration >= highestThreshold {
+				logLevel = threshold.Level
+				highestThreshold = threshold.Duration
+			}
+		}
+
+		if !logger.Enabled(ctx, logLevel) {
+			return
+		}
+
+		// The measured duration takes precedence over a 'duration' attribute given by the caller,
+		// since parseAttrs drops attributes with keys that are already in the parsed attributes
+		attrs := parseAttrs(
+			[]slog.Attr{slog.Duration(durationAttrKey, duration)},
+			logAttributes,
+		)
+
+		logger.logWithSource(ctx, logLevel, programCounters[0], message, nil, attrs, nil, nil)
+	})
+}
+
+const durationAttrKey = "duration"
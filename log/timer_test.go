@@ -0,0 +1,119 @@
+package log_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"hermannm.dev/devlog/log"
+	"hermannm.dev/devlog/log/logtest"
+)
+
+func TestTimer(t *testing.T) {
+	clock := logtest.NewFakeClock(logtest.GoldenTime)
+	logger, output := setupLogger()
+	logger = logger.WithClock(clock)
+
+	stop := logger.Timer(ctx, "Loaded config", "file", "config.yml")
+	clock.Advance(1500 * time.Millisecond)
+	stop()
+
+	verifyLogOutput(
+		t,
+		output.String(),
+		"INFO",
+		"Loaded config",
+		`"duration":1500000000,"file":"config.yml"`,
+	)
+}
+
+func TestObserve(t *testing.T) {
+	options := &log.TimerOptions{
+		Level:       slog.LevelDebug,
+		MinDuration: 100 * time.Millisecond,
+		SlowThresholds: []log.SlowThreshold{
+			{Duration: 5 * time.Second, Level: slog.LevelError},
+			{Duration: 1 * time.Second, Level: slog.LevelWarn},
+		},
+	}
+
+	for _, testCase := range []struct {
+		duration      time.Duration
+		expectedLevel string
+	}{
+		{50 * time.Millisecond, ""},
+		{100 * time.Millisecond, "DEBUG"},
+		{1 * time.Second, "WARN"},
+		{3 * time.Second, "WARN"},
+		{5 * time.Second, "ERROR"},
+	} {
+		t.Run(
+			testCase.duration.String(),
+			func(t *testing.T) {
+				clock := logtest.NewFakeClock(logtest.GoldenTime)
+				logger, output := setupLogger()
+				logger = logger.WithClock(clock)
+
+				stop := logger.Observe(ctx, "Query", options)
+				clock.Advance(testCase.duration)
+				stop()
+
+				if testCase.expectedLevel == "" {
+					if output.Len() != 0 {
+						t.Errorf("Expected no log below MinDuration, got: %s", output.String())
+					}
+					return
+				}
+
+				level, _, _ := parseLogOutput(t, output.String())
+				if level != testCase.expectedLevel {
+					unexpectedLogOutput(t, "log level", level, testCase.expectedLevel)
+				}
+			},
+		)
+	}
+}
+
+func TestTimerSource(t *testing.T) {
+	output := getLogOutputWithOptions(
+		&slog.HandlerOptions{AddSource: true},
+		func() {
+			stop := log.Timer(ctx, "Test")
+			stop()
+		},
+	)
+
+	assertContains(t, output, `"file":`, "timer_test.go", `"line":82`)
+}
+
+func TestTimerWithDurationAttr(t *testing.T) {
+	clock := logtest.NewFakeClock(logtest.GoldenTime)
+	logger, output := setupLogger()
+	logger = logger.WithClock(clock)
+
+	// The measured duration should replace the given 'duration' attribute, instead of giving a
+	// duplicate key
+	stop := logger.Timer(ctx, "Test", "duration", "custom", "key", "value")
+	clock.Advance(time.Second)
+	stop()
+
+	verifyLogOutput(t, output.String(), "INFO", "Test", `"duration":1000000000,"key":"value"`)
+}
+
+func TestTimerStoppedTwice(t *testing.T) {
+	clock := logtest.NewFakeClock(logtest.GoldenTime)
+	logger, output := setupLogger()
+	logger = logger.WithClock(clock)
+
+	stop := logger.Timer(ctx, "Test")
+	clock.Advance(time.Second)
+	stop()
+	clock.Advance(time.Second)
+	stop()
+
+	if count := strings.Count(output.String(), `"msg":"Test"`); count != 1 {
+		t.Errorf("Expected timer to log once, got %d logs: %s", count, output.String())
+	}
+	assertContains(t, output.String(), `"duration":1000000000`)
+}
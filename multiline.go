@@ -0,0 +1,187 @@
+package devlog
+
+import (
+	"encoding/json"
+	"strings"
+
+	"hermannm.dev/devlog/internal/limits"
+)
+
+// Writes the given string attribute value after the attribute key, truncated if it exceeds
+// [Options.MaxStringLength]. If the string spans multiple lines (such as SQL queries, stack dumps
+// or HTTP bodies), it's written as a block under the key, with each line indented one level deeper
+// than the attribute:
+//
+//	query:
+//	  SELECT *
+//	  FROM users
+//
+// If the block contains JSON or SQL, it's syntax highlighted (unless disabled by
+// [Options.DisableSyntaxHighlighting] or [Options.DisableColors]). We detect SQL by the block
+// starting with an uppercase statement keyword, such as SELECT or INSERT.
+func (handler *Handler) writeStringValue(buffer *byteBuffer, value string, indent int) {
+	// Ignores a single trailing newline, so that a string like "line\n" is not shown as a block
+	value = strings.TrimSuffix(value, "\n")
+
+	if !strings.Contains(value, "\n") {
+		buffer.writeByte(' ')
+		buffer.writeString(limits.TruncateString(value, handler.options.MaxStringLength))
+		buffer.writeByte('\n')
+		return
+	}
+
+	// Detects content type before truncating, since truncation may make JSON invalid
+	contentType := contentTypePlain
+	if !handler.options.DisableSyntaxHighlighting && !handler.options.DisableColors {
+		contentType = detectContentType(value)
+	}
+
+	value = limits.TruncateString(value, handler.options.MaxStringLength)
+
+	buffer.writeByte('\n')
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// Avoids trailing whitespace on empty lines
+		if line != "" {
+			buffer.writeIndent(indent + 1)
+			switch contentType {
+			case contentTypeJSON:
+				handler.writeJSONLine(buffer, line)
+			case contentTypeSQL:
+				handler.writeSQLLine(buffer, line)
+			case contentTypePlain:
+				buffer.writeString(line)
+			}
+		}
+		buffer.writeByte('\n')
+	}
+}
+
+type contentType int8
+
+const (
+	contentTypePlain contentType = iota
+	contentTypeJSON
+	contentTypeSQL
+)
+
+func detectContentType(value string) contentType {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return contentTypePlain
+	}
+
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return contentTypeJSON
+	}
+
+	// We only match uppercase keywords, since plain text may start with words like "Update" or
+	// "Create", and SQL in code is conventionally written with uppercase keywords
+	firstWord, _, _ := strings.Cut(trimmed, " ")
+	firstWord, _, _ = strings.Cut(firstWord, "\n")
+	switch firstWord {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "CREATE", "ALTER", "DROP", "MERGE":
+		return contentTypeSQL
+	}
+
+	return contentTypePlain
+}
+
+// Writes a line of JSON with the same colors as writeJSON: object keys in cyan, and punctuation in
+// gray. Since JSON strings can't contain raw newlines, we can highlight each line separately.
+func (handler *Handler) writeJSONLine(buffer *byteBuffer, line string) {
+	for index := 0; index < len(line); {
+		char := line[index]
+		switch char {
+		case '"':
+			end := findStringEnd(line, index, '"')
+			isKey := strings.HasPrefix(strings.TrimLeft(line[end:], " \t"), ":")
+			if isKey {
+				handler.setColor(buffer, colorCyan)
+			}
+			buffer.writeString(line[index:end])
+			if isKey {
+				handler.resetColor(buffer)
+			}
+			index = end
+		case '{', '}', '[', ']', ',', ':':
+			handler.writeByteWithColor(buffer, char, colorGray)
+			index++
+		default:
+			buffer.writeByte(char)
+			index++
+		}
+	}
+}
+
+// Writes a line of SQL, with keywords in magenta and comments in gray.
+func (handler *Handler) writeSQLLine(buffer *byteBuffer, line string) {
+	for index := 0; index < len(line); {
+		char := line[index]
+		switch {
+		case char == '\'' || char == '"' || char == '`':
+			end := findStringEnd(line, index, char)
+			buffer.writeString(line[index:end])
+			index = end
+		case strings.HasPrefix(line[index:], "--"):
+			handler.setColor(buffer, colorGray)
+			buffer.writeString(line[index:])
+			handler.resetColor(buffer)
+			index = len(line)
+		case isWordChar(char):
+			end := index + 1
+			for end < len(line) && isWordChar(line[end]) {
+				end++
+			}
+			word := line[index:end]
+			if sqlKeywords[strings.ToUpper(word)] {
+				handler.setColor(buffer, colorMagenta)
+				buffer.writeString(word)
+				handler.resetColor(buffer)
+			} else {
+				buffer.writeString(word)
+			}
+			index = end
+		default:
+			buffer.writeByte(char)
+			index++
+		}
+	}
+}
+
+// Returns the index after the closing quote of the string starting at the given index in the
+// given line, or the length of the line if the string is not closed on this line. Skips quotes
+// escaped with backslashes.
+func findStringEnd(line string, start int, quote byte) int {
+	for index := start + 1; index < len(line); index++ {
+		switch line[index] {
+		case '\\':
+			index++
+		case quote:
+			return index + 1
+		}
+	}
+	return len(line)
+}
+
+func isWordChar(char byte) bool {
+	return char == '_' ||
+		(char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9')
+}
+
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"INSERT": true, "INTO": true, "VALUES": true, "UPDATE": true, "SET": true, "DELETE": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "OUTER": true,
+	"CROSS": true, "ON": true, "USING": true, "AS": true, "DISTINCT": true, "GROUP": true,
+	"BY": true, "ORDER": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "ASC": true,
+	"DESC": true, "UNION": true, "ALL": true, "EXCEPT": true, "INTERSECT": true, "WITH": true,
+	"RETURNING": true, "CREATE": true, "ALTER": true, "DROP": true, "TABLE": true, "INDEX": true,
+	"VIEW": true, "IF": true, "EXISTS": true, "IN": true, "IS": true, "NULL": true, "LIKE": true,
+	"BETWEEN": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"CONFLICT": true, "DO": true, "NOTHING": true, "MERGE": true, "MATCHED": true, "TRUE": true,
+	"FALSE": true, "PRIMARY": true, "KEY": true, "FOREIGN": true, "REFERENCES": true,
+	"DEFAULT": true, "CONSTRAINT": true, "UNIQUE": true, "COUNT": true,
+}
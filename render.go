@@ -0,0 +1,153 @@
+package devlog
+
+import (
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/url"
+	"time"
+
+	"hermannm.dev/devlog/internal/limits"
+)
+
+// ValueRenderer formats attribute values for the log output of a [Handler]. It returns the
+// rendered value and true if it handles the given value, or false to fall back to the handler's
+// default formatting. Register renderers with [Options.ValueRenderers].
+//
+// The given value is the result of [slog.Value.Any], after resolving values that implement
+// [slog.LogValuer]. Use [RenderType] to create a renderer for a specific type.
+type ValueRenderer func(value any) (rendered string, ok bool)
+
+// RenderType creates a [ValueRenderer] for values of type T:
+//
+//	devlog.NewHandler(os.Stdout, &devlog.Options{
+//		ValueRenderers: []devlog.ValueRenderer{
+//			devlog.RenderType(func(id UserID) string { return "user#" + id.String() }),
+//		},
+//	})
+func RenderType[T any](render func(value T) string) ValueRenderer {
+	return func(value any) (rendered string, ok bool) {
+		typedValue, ok := value.(T)
+		if !ok {
+			return "", false
+		}
+		return render(typedValue), true
+	}
+}
+
+// ByteSize is a number of bytes. When used as a log attribute value, [Handler] formats it with
+// binary unit prefixes, rounded to 3 significant digits (such as 1.5 KiB or 12.3 MiB). Other
+// handlers (such as [slog.JSONHandler]) output it as a plain number.
+//
+//	slog.Info("Uploaded file", "size", devlog.ByteSize(len(file)))
+type ByteSize int64
+
+// String formats the byte size with binary unit prefixes, rounded to 3 significant digits.
+func (size ByteSize) String() string {
+	return limits.FormatByteSize(int64(size))
+}
+
+// Renders the given value with the first of the handler's [Options.ValueRenderers] that handles it.
+// Returns false if no renderer handled the value.
+func (handler *Handler) renderCustomValue(value slog.Value) (rendered string, ok bool) {
+	if len(handler.options.ValueRenderers) == 0 {
+		return "", false
+	}
+
+	anyValue := value.Any()
+	for _, render := range handler.options.ValueRenderers {
+		if rendered, ok := render(anyValue); ok {
+			return rendered, true
+		}
+	}
+	return "", false
+}
+
+// Writes values of types that we format specially, to make them more readable than their default
+// string or JSON formats. Returns false if the value's type is not one of these types.
+func (handler *Handler) writeKnownTypeValue(buffer *byteBuffer, value any) bool {
+	switch value := value.(type) {
+	case []byte:
+		handler.writeBytes(buffer, value)
+	case ByteSize:
+		buffer.writeString(value.String())
+	case net.IP:
+		buffer.writeString(value.String())
+	case *url.URL:
+		if value == nil {
+			return false
+		}
+		buffer.writeString(value.Redacted())
+	case url.URL:
+		buffer.writeString(value.Redacted())
+	default:
+		return false
+	}
+	return true
+}
+
+// Writes the given duration rounded to 3 significant digits (1.23s, 45.6ms), to make it easier to
+// read at a glance than the full nanosecond precision of [time.Duration.String]. Durations of a
+// minute or more are rounded to whole seconds (2m3s).
+func (handler *Handler) writeDuration(buffer *byteBuffer, duration time.Duration) {
+	handler.setColor(buffer, colorMagenta)
+	buffer.writeString(roundDuration(duration).String())
+	handler.resetColor(buffer)
+}
+
+func roundDuration(duration time.Duration) time.Duration {
+	if duration >= time.Minute || duration <= -time.Minute {
+		return duration.Round(time.Second)
+	}
+
+	precision := time.Duration(1)
+	for magnitude := duration / 1000; magnitude != 0; magnitude /= 10 {
+		precision *= 10
+	}
+	return duration.Round(precision)
+}
+
+// Writes the given time with date and time, like the log record time with [TimeFormatFull]. If the
+// time is not in the local time zone, the zone is included as well (2024-09-29 10:57:30 UTC), so
+// that it's not confused with local time.
+func (handler *Handler) writeTimeValue(buffer *byteBuffer, value time.Time) {
+	buffer.writeDateTime(value)
+
+	if value.Location() != time.Local {
+		zone, _ := value.Zone()
+		if zone == "" {
+			zone = value.Format("-07:00")
+		}
+		buffer.writeByte(' ')
+		buffer.writeString(zone)
+	}
+}
+
+// Maximum number of bytes to show when writing a byte slice, to avoid flooding the log output.
+const maxBytesPreview = 32
+
+// Writes the given bytes as hex, truncated to [maxBytesPreview] bytes, followed by the total
+// number of bytes (as in "68656c6c6f (5 bytes)").
+func (handler *Handler) writeBytes(buffer *byteBuffer, bytes []byte) {
+	preview := bytes[:min(len(bytes), maxBytesPreview)]
+
+	hexBytes := make([]byte, hex.EncodedLen(len(preview)))
+	hex.Encode(hexBytes, preview)
+	buffer.write(hexBytes)
+	if len(preview) < len(bytes) {
+		buffer.writeString("…")
+	}
+
+	if len(preview) > 0 {
+		buffer.writeByte(' ')
+	}
+	handler.setColor(buffer, colorGray)
+	buffer.writeByte('(')
+	buffer.writeDecimal(len(bytes))
+	if len(bytes) == 1 {
+		buffer.writeString(" byte)")
+	} else {
+		buffer.writeString(" bytes)")
+	}
+	handler.resetColor(buffer)
+}
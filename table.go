@@ -0,0 +1,207 @@
+package devlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Implemented by the attribute value from log.Table in the log subpackage. Should be the same
+// method as in log/table.go.
+type tableValuer interface {
+	TableRows() any
+}
+
+// Default for [Options.MaxTableColumnWidth].
+const defaultMaxTableColumnWidth = 40
+
+// Checks if the given value is a non-empty slice or array of structs or maps (or pointers to them),
+// which we can render as a table if [Options.RenderTables] is enabled. The rows may still turn out
+// to not be JSON objects (for example, structs that implement [json.Marshaler]), in which case
+// writeTable returns false.
+func isTableRows(value any) bool {
+	reflectValue := reflect.ValueOf(value)
+	if kind := reflectValue.Kind(); (kind != reflect.Slice && kind != reflect.Array) ||
+		reflectValue.Len() == 0 {
+		return false
+	}
+
+	elementType := reflectValue.Type().Elem()
+	for elementType.Kind() == reflect.Pointer {
+		elementType = elementType.Elem()
+	}
+	return elementType.Kind() == reflect.Struct || elementType.Kind() == reflect.Map
+}
+
+// Writes the given rows as a table under the attribute key, with a column for each JSON field in
+// the rows (in the order they first appear), and cells cut off at [Options.MaxTableColumnWidth]:
+//
+//	users:
+//	  id  name   email
+//	  1   Alice  alice@example.com
+//	  2   Bob    bob@example.com
+//
+// Returns false without writing anything if the rows are not a non-empty list of JSON objects.
+func (handler *Handler) writeTable(buffer *byteBuffer, rows any, indent int) bool {
+	columns, cells, ok := parseTableRows(rows)
+	if !ok || len(cells) == 0 {
+		return false
+	}
+
+	truncatedRows := 0
+	if maxRows := handler.options.MaxArrayElements; maxRows > 0 && len(cells) > maxRows {
+		truncatedRows = len(cells) - maxRows
+		cells = cells[:maxRows]
+	}
+
+	maxWidth := handler.options.MaxTableColumnWidth
+	if maxWidth <= 0 {
+		maxWidth = defaultMaxTableColumnWidth
+	}
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+		for _, row := range cells {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+		widths[i] = min(widths[i], maxWidth)
+	}
+
+	buffer.writeByte('\n')
+
+	buffer.writeIndent(indent + 1)
+	for i, column := range columns {
+		handler.setColor(buffer, colorCyan)
+		writeTableCell(buffer, column, widths[i], i == len(columns)-1)
+		handler.resetColor(buffer)
+	}
+	buffer.writeByte('\n')
+
+	for _, row := range cells {
+		// Stops at the last non-empty cell, so that we don't pad the row with trailing whitespace
+		lastCell := len(row) - 1
+		for lastCell > 0 && row[lastCell] == "" {
+			lastCell--
+		}
+
+		buffer.writeIndent(indent + 1)
+		for i, cell := range row[:lastCell+1] {
+			writeTableCell(buffer, cell, widths[i], i == lastCell)
+		}
+		buffer.writeByte('\n')
+	}
+
+	if truncatedRows > 0 {
+		buffer.writeIndent(indent + 1)
+		handler.setColor(buffer, colorGray)
+		buffer.writeString("…(truncated " + strconv.Itoa(truncatedRows))
+		if truncatedRows == 1 {
+			buffer.writeString(" row)")
+		} else {
+			buffer.writeString(" rows)")
+		}
+		handler.resetColor(buffer)
+		buffer.writeByte('\n')
+	}
+
+	return true
+}
+
+// Writes the given cell text, cut off with "…" if it's wider than the given width. Unless it's the
+// last cell in the row, it's padded with spaces to the column width, plus 2 spaces between columns.
+func writeTableCell(buffer *byteBuffer, text string, width int, isLast bool) {
+	textWidth := utf8.RuneCountInString(text)
+	if textWidth > width {
+		cutoff := 0
+		for range width - 1 {
+			_, size := utf8.DecodeRuneInString(text[cutoff:])
+			cutoff += size
+		}
+		text = text[:cutoff] + "…"
+		textWidth = width
+	}
+
+	buffer.writeString(text)
+	if !isLast {
+		for range width - textWidth + 2 {
+			buffer.writeByte(' ')
+		}
+	}
+}
+
+// Marshals the given rows to JSON, and parses them as a list of objects. Returns the object keys as
+// columns (in the order they first appear), and the cells for each row, with strings unquoted and
+// other values as compact JSON. Returns false if the rows are not a list of objects.
+func parseTableRows(rows any) (columns []string, cells [][]string, ok bool) {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, nil, false
+	}
+
+	columnIndexes := make(map[string]int)
+	var rowMaps []map[int]string
+	for decoder.More() {
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return nil, nil, false
+		}
+
+		row := make(map[int]string)
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, nil, false
+			}
+			column, _ := key.(string)
+
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, nil, false
+			}
+
+			columnIndex, exists := columnIndexes[column]
+			if !exists {
+				columnIndex = len(columns)
+				columnIndexes[column] = columnIndex
+				columns = append(columns, column)
+			}
+			row[columnIndex] = tableCellText(value)
+		}
+
+		// Consumes the closing '}'
+		if _, err := decoder.Token(); err != nil {
+			return nil, nil, false
+		}
+		rowMaps = append(rowMaps, row)
+	}
+
+	cells = make([][]string, len(rowMaps))
+	for i, row := range rowMaps {
+		cells[i] = make([]string, len(columns))
+		for columnIndex, cell := range row {
+			cells[i][columnIndex] = cell
+		}
+	}
+	return columns, cells, true
+}
+
+func tableCellText(value json.RawMessage) string {
+	var text string
+	if len(value) > 0 && value[0] == '"' {
+		if err := json.Unmarshal(value, &text); err != nil {
+			text = string(value)
+		}
+	} else {
+		text = string(value)
+	}
+
+	// Escapes newlines and tabs, so they don't break the table layout
+	return strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(text)
+}